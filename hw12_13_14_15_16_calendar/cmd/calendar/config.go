@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	config2 "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+)
+
+// configCommand обрабатывает `calendar config print`: выводит итоговую конфигурацию
+// с учётом файла, переменных окружения и значений по умолчанию, скрывая пароли.
+func configCommand(action string) int {
+	if action != "print" {
+		fmt.Fprintf(os.Stderr, "unknown config command %q, expected: config print\n", action)
+		return 2
+	}
+
+	config, err := config2.LoadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := config.Masked().WriteTOML(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -3,19 +3,33 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
 	config2 "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/health"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/idempotency"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/lifecycle"
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/notifier"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/outbox"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+	internalgrpc "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
 	internalhttp "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/http"
 	storage2 "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	cachestorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/cache"
 	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/webhook"
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/migrations"
 )
 
@@ -33,66 +47,257 @@ func main() {
 		return
 	}
 
+	if flag.Arg(0) == "config" {
+		os.Exit(configCommand(flag.Arg(1)))
+	}
+
+	if flag.Arg(0) == "migrate" {
+		os.Exit(migrateCommand(flag.Args()[1:]))
+	}
+
+	if flag.Arg(0) == "export" {
+		os.Exit(exportCommand(flag.Args()[1:]))
+	}
+
+	if flag.Arg(0) == "import" {
+		os.Exit(importCommand(flag.Args()[1:]))
+	}
+
+	os.Exit(run())
+}
+
+// run запускает сервис и возвращает код завершения процесса.
+func run() int {
 	config, err := config2.LoadConfig(configFile)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
 	logg, err := logger.New(config.Logger)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer logg.Close()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		logg.Error(err.Error())
+		return 1
 	}
 
 	if err = migrations.AutoMigrate(logg, config); err != nil {
-		panic(err)
+		logg.Error(err.Error())
+		return 1
 	}
 
+	manager := lifecycle.New(logg, config.Shutdown.Timeout)
+	manager.Add(lifecycle.Component{Name: "tracing", Stop: shutdownTracing})
+
 	var storage storage2.Storage
 
+	appMetrics := metrics.New()
+	checker := health.NewChecker(time.Second * 2)
+
 	storageType := config.Storage.StorageType
 
 	switch storageType {
 	case "postgres":
-		storage, err := sqlstorage.NewStorage(config.Storage.Dsn)
+		sqlStorage, err := sqlstorage.NewStorage(config.Storage)
 		if err != nil {
-			log.Fatal("Failed to connect to PostgreSQL:", err)
+			logg.Error("Failed to connect to PostgreSQL: " + err.Error())
+			return 1
+		}
+		storage = sqlStorage
+
+		manager.Add(lifecycle.Component{
+			Name: "storage",
+			Stop: func(context.Context) error { return sqlStorage.Close() },
+		})
+
+		checker.Add("storage", sqlStorage.Ping)
+		appMetrics.RegisterDBStats("primary", sqlStorage.DB())
+		if replica := sqlStorage.ReplicaDB(); replica != nil {
+			appMetrics.RegisterDBStats("replica", replica)
+			logg.Info("Using PostgreSQL read replica")
 		}
-		defer storage.Close()
+		checker.Add("migrations", func(ctx context.Context) error {
+			return migrations.CheckApplied(ctx, sqlStorage.DB())
+		})
 
 		logg.Info("Using PostgresSQL storage")
 	default:
+		storageType = "memory"
 		storage = memorystorage.NewStorage()
 		logg.Info("Using in-memory storage")
 	}
 
-	calendar := app.New(logg, storage)
+	storage = metrics.InstrumentStorage(storage, appMetrics, logg, storageType)
+	if config.Storage.Cache.Enabled {
+		storage = cachestorage.New(storage, appMetrics, config.Storage.Cache)
+		logg.Info(fmt.Sprintf("Using storage cache for %d entries with TTL %s",
+			config.Storage.Cache.Size, config.Storage.Cache.TTL))
+	}
+	appMetrics.RegisterEventsGauge(func() (int, error) {
+		return storage.Event().Count(context.Background())
+	})
 
-	server := internalhttp.NewServer(logg, calendar, config.Server)
+	dispatcher := webhook.NewDispatcher(logg, storage, config.Webhooks)
+	manager.Add(lifecycle.Component{Name: "webhooks", Stop: dispatcher.Close})
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-	defer cancel()
+	sender, err := notifier.NewSender(logg, storage, appMetrics, config.Notifier)
+	if err != nil {
+		logg.Error(err.Error())
+		return 1
+	}
 
-	context.AfterFunc(ctx, func() {
-		logg.Info("calendar is stopping...")
+	calendar := app.New(logg, storage, dispatcher, sender)
+
+	// Очередь в памяти процесса: уведомления из outbox отправляются тем же сервисом.
+	notifyQueue := queue.NewMemory(logg, config.Outbox)
+	manager.Add(lifecycle.Component{
+		Name: "notification consumer",
+		Run: func(ctx context.Context) error {
+			notifyQueue.Consume(ctx, calendar.HandleNotification)
+			return nil
+		},
 	})
 
-	go func() {
-		<-ctx.Done()
+	relay := outbox.New(logg, storage, notifyQueue, config.Outbox)
+	manager.Add(lifecycle.Component{
+		Name: "outbox relay",
+		Run: func(ctx context.Context) error {
+			relay.Run(ctx)
+			return nil
+		},
+	})
+
+	notifyScheduler := scheduler.New(logg, calendar, appMetrics, config.Scheduler)
+	manager.Add(lifecycle.Component{
+		Name: "scheduler",
+		Run: func(ctx context.Context) error {
+			notifyScheduler.Run(ctx)
+			return nil
+		},
+	})
+
+	authenticator, err := auth.New(config.Auth)
+	if err != nil {
+		logg.Error(fmt.Sprintf("failed to configure auth: %v", err))
+		return 1
+	}
+
+	idempotencyGuard := idempotency.New(logg, storage, config.Idempotency)
+	manager.Add(lifecycle.Component{
+		Name: "idempotency cleanup",
+		Run: func(ctx context.Context) error {
+			idempotencyGuard.Run(ctx)
+			return nil
+		},
+	})
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+	eventService := internalgrpc.NewEventService(logg, calendar, idempotencyGuard)
+	webhookService := internalgrpc.NewWebhookService(logg, calendar)
+	channelService := internalgrpc.NewChannelService(logg, calendar)
+	gateway, err := internalgrpc.NewGateway(context.Background(), eventService, webhookService, channelService)
+	if err != nil {
+		logg.Error(fmt.Sprintf("failed to configure gateway: %v", err))
+		return 1
+	}
+
+	server := internalhttp.NewServer(logg, appMetrics, checker, config.Server, config.CORS,
+		config.RateLimit, ratelimit.NewMemoryStore(10*time.Minute), authenticator, gateway, storage)
+	grpcServer := internalgrpc.NewServer(logg, appMetrics, checker, authenticator,
+		eventService, webhookService, channelService, config.Server)
+
+	reloader := config2.NewReloader(logg, configFile, config)
+	reloader.OnReload(func(conf *config2.Config) error {
+		return logg.SetLogLevel(conf.Logger.Level)
+	})
+	reloader.OnReload(func(conf *config2.Config) error {
+		notifyScheduler.SetInterval(conf.Scheduler.Interval)
+		return nil
+	})
+	reloader.OnReload(func(conf *config2.Config) error {
+		server.SetCORSOrigins(conf.CORS.AllowedOrigins)
+		server.SetRateLimits(conf.RateLimit)
+		return nil
+	})
+	manager.Add(lifecycle.Component{Name: "config watcher", Run: reloader.Watch})
 
-		if err := server.Stop(ctx); err != nil {
-			logg.Error("failed to stop http server: " + err.Error())
+	manager.Add(lifecycle.Component{
+		Name:  "grpc server",
+		Start: grpcServer.Start,
+		Run:   func(context.Context) error { return grpcServer.Serve() },
+		Stop:  grpcServer.Stop,
+	})
+	manager.Add(lifecycle.Component{
+		Name:  "http server",
+		Start: server.Start,
+		Run:   func(context.Context) error { return server.Serve() },
+		Stop:  server.Stop,
+	})
+
+	// Останавливается первым: readiness уходит в fail, и до остановки серверов есть
+	// DrainDelay, чтобы балансировщик успел заметить это и переключить трафик.
+	manager.Add(lifecycle.Component{
+		Name: "health",
+		Stop: func(ctx context.Context) error {
+			checker.SetShuttingDown()
+			if config.Shutdown.DrainDelay <= 0 {
+				return nil
+			}
+			logg.Info(fmt.Sprintf("waiting %s for traffic to drain", config.Shutdown.DrainDelay))
+			select {
+			case <-time.After(config.Shutdown.DrainDelay):
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// SIGHUP не останавливает сервис: переоткрывает файл лога после внешней ротации
+	// и перечитывает конфигурацию.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := logg.Reopen(); err != nil {
+					logg.Error("failed to reopen log file: " + err.Error())
+				}
+				if err := reloader.Reload(); err != nil {
+					logg.Error("failed to reload config: " + err.Error())
+				}
+			}
 		}
 	}()
 
+	context.AfterFunc(ctx, func() {
+		logg.Info("calendar is stopping...")
+	})
+
 	logg.Info("calendar is running...")
 
-	if err := server.Start(ctx); err != nil {
-		logg.Error("failed to start http server: " + err.Error())
-		cancel()
-		os.Exit(1) //nolint:gocritic
+	if err := manager.Run(ctx); err != nil {
+		logg.Error("calendar stopped with error: " + err.Error())
+		return 1
 	}
+
+	logg.Info("calendar stopped")
+	return 0
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	config2 "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/migrations"
+)
+
+const migrateUsage = "usage: calendar migrate [-dry-run] up|down|status|redo|create <name>"
+
+// migrateCommand обрабатывает `calendar migrate ...` с теми же конфигом и логгером, что и сервис.
+func migrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "Print SQL instead of executing it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	command := flags.Arg(0)
+	switch command {
+	case "up", "down", "redo", "status", "create":
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	config, err := config2.LoadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	logg, err := logger.New(config.Logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer logg.Close()
+
+	if command == "create" {
+		if err := migrations.Create(logg, config.Migrations.Dir, flags.Arg(1)); err != nil {
+			logg.Error(err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	ctx := context.Background()
+
+	db, err := migrations.Open(ctx, config.Storage.Dsn)
+	if err != nil {
+		logg.Error(err.Error())
+		return 1
+	}
+	defer db.Close()
+
+	migrator := migrations.NewMigrator(logg, db, nil)
+	if *dryRun {
+		migrator = migrations.NewMigrator(logg, db, os.Stdout)
+	}
+
+	switch command {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "redo":
+		err = migrator.Redo(ctx)
+	default:
+		err = migrator.Status(ctx)
+	}
+
+	if err != nil {
+		logg.Error(err.Error())
+		return 1
+	}
+	return 0
+}
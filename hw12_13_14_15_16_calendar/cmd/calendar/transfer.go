@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	config2 "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	sqlstorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/transfer"
+)
+
+const (
+	exportUsage = "usage: calendar export [-format=jsonl|ics] > file"
+	importUsage = "usage: calendar import [-state=file] < file"
+)
+
+// exportCommand обрабатывает `calendar export`: события пишутся в stdout, ход выгрузки — в stderr.
+func exportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	rawFormat := flags.String("format", string(transfer.FormatJSONL), "Output format: jsonl or ics")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	format, err := transfer.ParseFormat(*rawFormat)
+	if err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, exportUsage)
+		return 2
+	}
+
+	storage, err := openTransferStorage("export")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer storage.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Без общего числа событий ход выгрузки всё равно показывается, только без итога.
+	total, _ := storage.Event().Count(ctx)
+	progress := &progress{action: "exported", total: total}
+
+	n, err := transfer.Export(ctx, storage, transfer.AllUsers, os.Stdout, format, progress.report)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	progress.print(n)
+	return 0
+}
+
+// importCommand обрабатывает `calendar import`: загружает выгрузку JSONL из stdin.
+// Положение загрузки вместе с хешем уже загруженных строк сохраняется в файл состояния
+// после каждой страницы, поэтому повторный запуск с тем же входом продолжает загрузку,
+// а с другим — отказывается; после успеха файл удаляется.
+func importCommand(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	statePath := flags.String("state", "calendar-import.state",
+		"File with the position of an interrupted import, used to resume it with the same input")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, importUsage)
+		return 2
+	}
+
+	from, err := readImportState(*statePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	storage, err := openTransferStorage("import")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer storage.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if from.Lines > 0 {
+		fmt.Fprintf(os.Stderr, "resuming import after line %d\n", from.Lines)
+	}
+
+	progress := &progress{action: "imported"}
+	checkpoint := func(cp transfer.Checkpoint, result transfer.Result) error {
+		progress.report(result.Imported + result.Existing)
+		return writeImportState(*statePath, cp)
+	}
+	result, err := transfer.Import(ctx, storage, os.Stdin, from, checkpoint)
+	if errors.Is(err, transfer.ErrCheckpointMismatch) {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintf(os.Stderr, "%s belongs to another input, remove it to import from the start\n", *statePath)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintf(os.Stderr, "progress is saved to %s, run the same command to resume\n", *statePath)
+		return 1
+	}
+
+	if err := os.Remove(*statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d events (%d with new IDs), %d already present\n",
+		result.Imported, result.Renumbered, result.Existing)
+	return 0
+}
+
+// openTransferStorage открывает хранилище из конфигурации. Хранилище в памяти живёт только
+// внутри запущенного сервиса, отдельной команде выгружать из него нечего: там каждый
+// пользователь может скачать свои события через GET /events:export.
+func openTransferStorage(command string) (*sqlstorage.Storage, error) {
+	config, err := config2.LoadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Storage.StorageType != "postgres" {
+		return nil, fmt.Errorf("%s requires postgres storage, configured: %s", command,
+			config.Storage.StorageType)
+	}
+	return sqlstorage.NewStorage(config.Storage)
+}
+
+func readImportState(path string) (transfer.Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return transfer.Checkpoint{}, nil
+	}
+	if err != nil {
+		return transfer.Checkpoint{}, err
+	}
+
+	var cp transfer.Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil || cp.Lines < 0 || cp.Digest == "" {
+		return transfer.Checkpoint{}, fmt.Errorf("invalid import state in %s: %q", path, data)
+	}
+	return cp, nil
+}
+
+// writeImportState заменяет файл целиком, чтобы прерванная запись не испортила состояние.
+func writeImportState(path string, cp transfer.Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// progress печатает в stderr ход выгрузки или загрузки не чаще раза в секунду.
+type progress struct {
+	action  string
+	total   int
+	printed time.Time
+}
+
+func (p *progress) report(done int) {
+	if time.Since(p.printed) < time.Second {
+		return
+	}
+	p.print(done)
+}
+
+func (p *progress) print(done int) {
+	p.printed = time.Now()
+	if p.total > 0 {
+		fmt.Fprintf(os.Stderr, "%s %d/%d events\n", p.action, done, p.total)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %d events\n", p.action, done)
+}
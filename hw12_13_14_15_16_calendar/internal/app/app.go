@@ -1,15 +1,30 @@
 package app
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/app")
+
 type App struct {
-	logger  Logger
-	storage Storage
+	logger    Logger
+	storage   Storage
+	publisher EventPublisher
+	sender    NotificationSender
 }
 
 type Logger interface {
@@ -17,50 +32,447 @@ type Logger interface {
 	Error(args ...interface{})
 	Debug(args ...interface{})
 	Warn(args ...interface{})
+	InfoContext(ctx context.Context, args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
 }
 
 type Storage interface {
 	Event() storage.EventRepository
+	Webhook() storage.WebhookRepository
+	Channel() storage.ChannelRepository
+	Reminder() storage.ReminderRepository
+	Outbox() storage.OutboxRepository
 }
 
 type EventRepository interface {
-	Create(e *domain.Event) error
-	Update(id int, e *domain.Event) error
-	Delete(id int) error
-	Get(id int) (domain.Event, error)
-	ListByDay(date time.Time) ([]domain.Event, error)
-	ListByWeek(date time.Time) ([]domain.Event, error)
-	ListByMonth(date time.Time) ([]domain.Event, error)
+	Create(ctx context.Context, e *domain.Event) error
+	Update(ctx context.Context, id int, e *domain.Event) error
+	Delete(ctx context.Context, id int) error
+	Get(ctx context.Context, id int) (domain.Event, error)
+	ListByDay(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByWeek(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByMonth(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, action domain.EventAction, event domain.Event)
+}
+
+type NotificationSender interface {
+	Send(ctx context.Context, n domain.Notification) error
+}
+
+func New(logger Logger, storage Storage, publisher EventPublisher, sender NotificationSender) *App {
+	return &App{logger: logger, storage: storage, publisher: publisher, sender: sender}
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "App."+name, trace.WithAttributes(attrs...))
 }
 
-func New(logger Logger, storage Storage) *App {
-	return &App{logger: logger, storage: storage}
+func (a *App) GetEvent(ctx context.Context, id int) (_ domain.Event, err error) {
+	ctx = logger.WithField(ctx, logger.FieldEventID, id)
+	ctx, span := startSpan(ctx, "GetEvent", attribute.Int("event.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Event().Get(ctx, id)
 }
 
-func (a *App) GetEvent(id int) (domain.Event, error) {
-	return a.storage.Event().Get(id)
+// GetEventForUpdate читает событие из основной базы: проверка перед изменением
+// не должна зависеть от отставания реплики.
+func (a *App) GetEventForUpdate(ctx context.Context, id int) (domain.Event, error) {
+	return a.GetEvent(storage.WithPrimary(ctx), id)
 }
 
-func (a *App) UpdateEvent(id int, event *domain.Event) error {
-	return a.storage.Event().Update(id, event)
+func (a *App) UpdateEvent(ctx context.Context, id int, event *domain.Event) (err error) {
+	ctx = logger.WithField(ctx, logger.FieldEventID, id)
+	ctx, span := startSpan(ctx, "UpdateEvent", attribute.Int("event.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	if err := a.storage.Event().Update(ctx, id, event); err != nil {
+		return err
+	}
+	a.logger.InfoContext(ctx, "event updated")
+	a.publisher.Publish(ctx, domain.EventUpdated, *event)
+	return nil
 }
 
-func (a *App) CreateEvent(event *domain.Event) error {
-	return a.storage.Event().Create(event)
+func (a *App) CreateEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := startSpan(ctx, "CreateEvent")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	if err := a.storage.Event().Create(ctx, event); err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("event.id", event.ID))
+	ctx = logger.WithField(ctx, logger.FieldEventID, event.ID)
+	a.logger.InfoContext(ctx, "event created")
+	a.publisher.Publish(ctx, domain.EventCreated, *event)
+	return nil
 }
 
-func (a *App) ListByDay(date time.Time) ([]domain.Event, error) {
-	return a.storage.Event().ListByDay(date)
+func (a *App) ListByDay(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	ctx, span := startSpan(ctx, "ListByDay",
+		attribute.Int("user.id", userID), attribute.String("date", date.Format(time.DateOnly)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Event().ListByDay(ctx, userID, date, tags)
+}
+
+func (a *App) ListByWeek(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	ctx, span := startSpan(ctx, "ListByWeek",
+		attribute.Int("user.id", userID), attribute.String("date", date.Format(time.DateOnly)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Event().ListByWeek(ctx, userID, date, tags)
+}
+
+func (a *App) ListByMonth(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	ctx, span := startSpan(ctx, "ListByMonth",
+		attribute.Int("user.id", userID), attribute.String("date", date.Format(time.DateOnly)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Event().ListByMonth(ctx, userID, date, tags)
+}
+
+func (a *App) SearchEvents(ctx context.Context, q domain.SearchQuery) (_ []domain.SearchResult, err error) {
+	ctx, span := startSpan(ctx, "SearchEvents", attribute.Int("search.limit", q.Limit))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	results, err := a.storage.Event().Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("search.results", len(results)))
+	return results, nil
 }
 
-func (a *App) ListByWeek(date time.Time) ([]domain.Event, error) {
-	return a.storage.Event().ListByWeek(date)
+func (a *App) TagCounts(ctx context.Context, userID int) (_ []domain.TagCount, err error) {
+	ctx, span := startSpan(ctx, "TagCounts")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Event().TagCounts(ctx, userID)
+}
+
+func (a *App) DeleteEvent(ctx context.Context, id int) (err error) {
+	ctx = logger.WithField(ctx, logger.FieldEventID, id)
+	ctx, span := startSpan(ctx, "DeleteEvent", attribute.Int("event.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	event, err := a.storage.Event().Get(storage.WithPrimary(ctx), id)
+	if err != nil {
+		return err
+	}
+	if err := a.storage.Event().Delete(ctx, id); err != nil {
+		return err
+	}
+	a.logger.InfoContext(ctx, "event deleted")
+	a.publisher.Publish(ctx, domain.EventDeleted, event)
+	return nil
+}
+
+// CreateEvents создаёт пакет событий. Ошибки отдельных событий возвращаются в errs
+// по индексам events, а err означает, что пакет не удалось применить целиком.
+func (a *App) CreateEvents(
+	ctx context.Context, events []domain.Event, mode domain.BatchMode,
+) (errs []error, err error) {
+	ctx, span := startBatchSpan(ctx, "CreateEvents", len(events), mode)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	errs, batch, indexes, err := validateBatch(events, mode)
+	if err != nil || batch == nil {
+		return errs, err
+	}
+
+	if err := a.storage.Event().CreateBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	a.logger.InfoContext(ctx, fmt.Sprintf("%d of %d events created", len(indexes), len(events)))
+	for _, e := range batch {
+		a.publisher.Publish(ctx, domain.EventCreated, *e)
+	}
+	return errs, nil
+}
+
+// UpdateEvents обновляет пакет событий userID; чужие события считаются несуществующими.
+func (a *App) UpdateEvents(
+	ctx context.Context, userID int, events []domain.Event, mode domain.BatchMode,
+) (errs []error, err error) {
+	ctx, span := startBatchSpan(ctx, "UpdateEvents", len(events), mode)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	errs, batch, indexes, err := validateBatch(events, mode)
+	if err != nil || batch == nil {
+		return errs, err
+	}
+
+	batchErrs, err := a.storage.Event().UpdateBatch(ctx, userID, batch, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := 0
+	for i, idx := range indexes {
+		errs[idx] = batchErrs[i]
+		if batchErrs[i] == nil {
+			updated++
+			a.publisher.Publish(ctx, domain.EventUpdated, *batch[i])
+		}
+	}
+
+	a.logger.InfoContext(ctx, fmt.Sprintf("%d of %d events updated", updated, len(events)))
+	return errs, nil
+}
+
+// DeleteEvents удаляет пакет событий userID; чужие события считаются несуществующими.
+func (a *App) DeleteEvents(
+	ctx context.Context, userID int, ids []int, mode domain.BatchMode,
+) (errs []error, err error) {
+	ctx, span := startBatchSpan(ctx, "DeleteEvents", len(ids), mode)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := domain.ValidateBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	deleted, errs, err := a.storage.Event().DeleteBatch(ctx, userID, ids, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for i := range deleted {
+		if errs[i] == nil {
+			count++
+			a.publisher.Publish(ctx, domain.EventDeleted, deleted[i])
+		}
+	}
+
+	a.logger.InfoContext(ctx, fmt.Sprintf("%d of %d events deleted", count, len(ids)))
+	return errs, nil
+}
+
+func startBatchSpan(ctx context.Context, name string, size int, mode domain.BatchMode) (context.Context, trace.Span) {
+	return startSpan(ctx, name, attribute.Int("batch.size", size), attribute.String("batch.mode", string(mode)))
+}
+
+// validateBatch проверяет события пакета и отбирает корректные вместе с их индексами.
+// batch равен nil, если применять нечего: все события отклонены или пакет отменён.
+func validateBatch(
+	events []domain.Event, mode domain.BatchMode,
+) (errs []error, batch []*domain.Event, indexes []int, err error) {
+	if err := domain.ValidateBatchSize(len(events)); err != nil {
+		return nil, nil, nil, err
+	}
+
+	errs = make([]error, len(events))
+	for i := range events {
+		if errs[i] = events[i].Validate(); errs[i] == nil {
+			batch = append(batch, &events[i])
+			indexes = append(indexes, i)
+		}
+	}
+
+	if mode == domain.BatchAllOrNothing && len(batch) < len(events) {
+		domain.AbortBatch(errs)
+		return errs, nil, nil, nil
+	}
+	return errs, batch, indexes, nil
+}
+
+func (a *App) ListDueReminders(ctx context.Context, now time.Time) (_ []domain.Reminder, err error) {
+	ctx, span := startSpan(ctx, "ListDueReminders")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Reminder().ListDue(ctx, now)
+}
+
+// SendReminder помечает напоминание отправленным и в той же транзакции ставит уведомление
+// в outbox, откуда его публикует в очередь outbox.Relay. Сбой между этими шагами
+// не приводит ни к потере, ни к повторной постановке уведомления.
+func (a *App) SendReminder(ctx context.Context, reminder domain.Reminder) (err error) {
+	ctx, span := startSpan(ctx, "SendReminder",
+		attribute.Int("reminder.id", reminder.ID), attribute.Int("event.id", reminder.EventID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	event, err := a.storage.Event().Get(ctx, reminder.EventID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(domain.NewNotification(event))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	msg := domain.OutboxMessage{Topic: domain.TopicNotifications, Payload: payload, CreatedAt: now}
+	return a.storage.Reminder().Fire(ctx, reminder.ID, now, &msg)
+}
+
+// HandleNotification отправляет уведомление из очереди и сообщает о нём вебхукам.
+// Очередь доставляет сообщения не меньше одного раза, поэтому обработанные сообщения
+// отмечаются в outbox, а повторы с тем же ID пропускаются. Отправка повторится,
+// только если процесс упадёт между Send и отметкой.
+func (a *App) HandleNotification(ctx context.Context, msg domain.OutboxMessage) (err error) {
+	if msg.Topic != domain.TopicNotifications {
+		return fmt.Errorf("unexpected topic %q", msg.Topic)
+	}
+
+	var n domain.Notification
+	if err := json.Unmarshal(msg.Payload, &n); err != nil {
+		return fmt.Errorf("failed to decode notification: %w", err)
+	}
+
+	ctx = logger.WithFields(ctx, logger.Fields{logger.FieldEventID: n.EventID, logger.FieldUserID: n.UserID})
+	ctx, span := startSpan(ctx, "HandleNotification",
+		attribute.Int("event.id", n.EventID), attribute.Int("outbox.id", msg.ID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Отметку мог сохранить другой экземпляр, поэтому читаем с основной базы.
+	processed, err := a.storage.Outbox().Processed(storage.WithPrimary(ctx), msg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check notification: %w", err)
+	}
+	if processed {
+		a.logger.InfoContext(ctx, fmt.Sprintf("notification %d already processed, skipping", msg.ID))
+		return nil
+	}
+
+	if err := a.sender.Send(ctx, n); err != nil {
+		a.logger.ErrorContext(ctx, "failed to send notification: "+err.Error())
+		return err
+	}
+
+	// Уведомление уже отправлено: ошибка отметки не должна вызвать повторную отправку.
+	if err := a.storage.Outbox().MarkProcessed(context.WithoutCancel(ctx), msg.ID, time.Now().UTC()); err != nil {
+		a.logger.ErrorContext(ctx, "failed to mark notification processed: "+err.Error())
+	}
+
+	// Событие могли удалить после срабатывания напоминания, тогда сообщать вебхукам не о чем.
+	event, err := a.storage.Event().Get(ctx, n.EventID)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	a.publisher.Publish(ctx, domain.EventNotified, event)
+	return nil
+}
+
+func (a *App) GetNotificationChannel(ctx context.Context, userID int) (_ domain.NotificationChannel, err error) {
+	ctx, span := startSpan(ctx, "GetNotificationChannel", attribute.Int("user.id", userID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	channel, err := a.storage.Channel().Get(ctx, userID)
+	if errors.Is(err, domain.ErrChannelNotFound) {
+		return domain.NotificationChannel{UserID: userID, Type: domain.ChannelLog}, nil
+	}
+	return channel, err
+}
+
+func (a *App) SetNotificationChannel(ctx context.Context, channel *domain.NotificationChannel) (err error) {
+	ctx, span := startSpan(ctx, "SetNotificationChannel", attribute.Int("user.id", channel.UserID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := channel.Validate(); err != nil {
+		return err
+	}
+	return a.storage.Channel().Set(ctx, channel)
+}
+
+func (a *App) ResetNotificationChannel(ctx context.Context, userID int) (err error) {
+	ctx, span := startSpan(ctx, "ResetNotificationChannel", attribute.Int("user.id", userID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	err = a.storage.Channel().Delete(ctx, userID)
+	if errors.Is(err, domain.ErrChannelNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (a *App) RegisterWebhook(ctx context.Context, hook *domain.Webhook) (err error) {
+	ctx, span := startSpan(ctx, "RegisterWebhook", attribute.Int("user.id", hook.UserID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := hook.Validate(); err != nil {
+		return err
+	}
+
+	if hook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		hook.Secret = secret
+	}
+	hook.CreatedAt = time.Now().UTC()
+
+	return a.storage.Webhook().Create(ctx, hook)
+}
+
+func (a *App) ListWebhooks(ctx context.Context, userID int) (_ []domain.Webhook, err error) {
+	ctx, span := startSpan(ctx, "ListWebhooks", attribute.Int("user.id", userID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return a.storage.Webhook().ListByUser(ctx, userID)
+}
+
+func (a *App) DeleteWebhook(ctx context.Context, userID, id int) (err error) {
+	ctx, span := startSpan(ctx, "DeleteWebhook", attribute.Int("user.id", userID), attribute.Int("webhook.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if _, err := a.getUserWebhook(storage.WithPrimary(ctx), userID, id); err != nil {
+		return err
+	}
+	return a.storage.Webhook().Delete(ctx, id)
+}
+
+func (a *App) ListWebhookDeliveries(ctx context.Context, userID, id int) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "ListWebhookDeliveries",
+		attribute.Int("user.id", userID), attribute.Int("webhook.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if _, err := a.getUserWebhook(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return a.storage.Webhook().ListDeliveries(ctx, id)
 }
 
-func (a *App) ListByMonth(date time.Time) ([]domain.Event, error) {
-	return a.storage.Event().ListByMonth(date)
+func (a *App) getUserWebhook(ctx context.Context, userID, id int) (domain.Webhook, error) {
+	hook, err := a.storage.Webhook().Get(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if hook.UserID != userID {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return hook, nil
 }
 
-func (a *App) DeleteEvent(id int) error {
-	return a.storage.Event().Delete(id)
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
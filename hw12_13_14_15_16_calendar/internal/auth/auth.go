@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Credentials — данные, которые клиент передал для аутентификации.
+type Credentials struct {
+	BearerToken string
+	APIKey      string
+}
+
+func (c Credentials) Empty() bool {
+	return c.BearerToken == "" && c.APIKey == ""
+}
+
+// ParseAuthorization извлекает токен из значения заголовка "Authorization: Bearer <token>".
+func ParseAuthorization(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+type verificationKey struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+// Authenticator проверяет JWT (HS256/RS256) и статические API-ключи.
+type Authenticator struct {
+	enabled bool
+	keys    []verificationKey
+	apiKeys map[[sha256.Size]byte]int
+	public  map[string]bool
+	parser  *jwt.Parser
+}
+
+func New(conf config.AuthConf) (*Authenticator, error) {
+	a := &Authenticator{
+		enabled: conf.Enabled,
+		apiKeys: make(map[[sha256.Size]byte]int, len(conf.APIKeys)),
+		public:  make(map[string]bool, len(conf.PublicRoutes)),
+	}
+
+	for _, route := range conf.PublicRoutes {
+		a.public[route] = true
+	}
+
+	// Ключи хранятся в виде хэшей, чтобы не держать их в памяти открытым текстом.
+	for _, key := range conf.APIKeys {
+		a.apiKeys[sha256.Sum256([]byte(key.Key))] = key.UserID
+	}
+
+	for _, keyConf := range conf.JWT.Keys {
+		key, err := loadKey(keyConf)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyConf.ID, err)
+		}
+		a.keys = append(a.keys, verificationKey{id: keyConf.ID, algorithm: keyConf.Algorithm, key: key})
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if conf.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.JWT.Issuer))
+	}
+	if conf.JWT.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func loadKey(conf config.JWTKeyConf) (interface{}, error) {
+	switch conf.Algorithm {
+	case "HS256":
+		return []byte(conf.Secret), nil
+	case "RS256":
+		data, err := os.ReadFile(conf.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return jwt.ParseRSAPublicKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", conf.Algorithm)
+	}
+}
+
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Required сообщает, нужна ли аутентификация для маршрута: шаблона HTTP или метода gRPC.
+func (a *Authenticator) Required(route string) bool {
+	return a.enabled && !a.public[route]
+}
+
+// Authenticate возвращает идентификатор пользователя. API-ключ проверяется первым.
+func (a *Authenticator) Authenticate(_ context.Context, creds Credentials) (int, error) {
+	if creds.APIKey != "" {
+		if userID, ok := a.apiKeys[sha256.Sum256([]byte(creds.APIKey))]; ok {
+			return userID, nil
+		}
+		return 0, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+
+	if creds.BearerToken != "" {
+		return a.authenticateToken(creds.BearerToken)
+	}
+
+	return 0, fmt.Errorf("%w: no credentials", ErrUnauthenticated)
+}
+
+func (a *Authenticator) authenticateToken(raw string) (int, error) {
+	var lastErr error
+
+	for _, key := range a.keys {
+		var claims jwt.RegisteredClaims
+		_, err := a.parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+			if kid, ok := token.Header["kid"].(string); ok && kid != key.id {
+				return nil, errors.New("key id mismatch")
+			}
+			if token.Method.Alg() != key.algorithm {
+				return nil, errors.New("algorithm mismatch")
+			}
+			return key.key, nil
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		userID, err := strconv.Atoi(claims.Subject)
+		if err != nil || userID <= 0 {
+			return 0, fmt.Errorf("%w: subject must be a user id", ErrUnauthenticated)
+		}
+		return userID, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no jwt keys configured")
+	}
+	return 0, fmt.Errorf("%w: %w", ErrUnauthenticated, lastErr)
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.RegisteredClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func validClaims(subject string) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    "calendar-test",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestAuthenticator_HS256(t *testing.T) {
+	a, err := New(config.AuthConf{
+		Enabled: true,
+		JWT: config.JWTConf{
+			Issuer: "calendar-test",
+			Keys:   []config.JWTKeyConf{{ID: "main", Algorithm: "HS256", Secret: "secret"}},
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	userID, err := a.Authenticate(ctx, Credentials{BearerToken: sign(t, jwt.SigningMethodHS256, []byte("secret"), "main",
+		validClaims("42"))})
+	require.NoError(t, err)
+	assert.Equal(t, 42, userID)
+
+	// Токен без kid проверяется всеми ключами.
+	_, err = a.Authenticate(ctx, Credentials{BearerToken: sign(t, jwt.SigningMethodHS256, []byte("secret"), "",
+		validClaims("42"))})
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"wrong secret": sign(t, jwt.SigningMethodHS256, []byte("other"), "main", validClaims("42")),
+		"unknown kid":  sign(t, jwt.SigningMethodHS256, []byte("secret"), "old", validClaims("42")),
+		"bad subject":  sign(t, jwt.SigningMethodHS256, []byte("secret"), "main", validClaims("alice")),
+		"expired": sign(t, jwt.SigningMethodHS256, []byte("secret"), "main", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "calendar-test",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		}),
+		"wrong issuer": sign(t, jwt.SigningMethodHS256, []byte("secret"), "main", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "someone-else",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}),
+		"garbage": "not-a-token",
+	}
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Authenticate(ctx, Credentials{BearerToken: token})
+			assert.ErrorIs(t, err, ErrUnauthenticated)
+		})
+	}
+}
+
+func TestAuthenticator_RS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "jwt.pub")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	a, err := New(config.AuthConf{
+		Enabled: true,
+		JWT: config.JWTConf{Keys: []config.JWTKeyConf{
+			{ID: "hmac", Algorithm: "HS256", Secret: "secret"},
+			{ID: "rsa", Algorithm: "RS256", PublicKeyFile: keyFile},
+		}},
+	})
+	require.NoError(t, err)
+
+	userID, err := a.Authenticate(context.Background(), Credentials{
+		BearerToken: sign(t, jwt.SigningMethodRS256, privateKey, "rsa", validClaims("7")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, userID)
+
+	// HS256-токен, подписанный открытым RSA-ключом, не должен приниматься.
+	_, err = a.Authenticate(context.Background(), Credentials{
+		BearerToken: sign(t, jwt.SigningMethodHS256, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+			"rsa", validClaims("7")),
+	})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestAuthenticator_APIKeysAndRoutes(t *testing.T) {
+	a, err := New(config.AuthConf{
+		Enabled:      true,
+		APIKeys:      []config.APIKeyConf{{Key: "k1", UserID: 3}},
+		PublicRoutes: []string{"GET /healthz"},
+	})
+	require.NoError(t, err)
+
+	userID, err := a.Authenticate(context.Background(), Credentials{APIKey: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, userID)
+
+	_, err = a.Authenticate(context.Background(), Credentials{APIKey: "k2"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = a.Authenticate(context.Background(), Credentials{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	assert.False(t, a.Required("GET /healthz"))
+	assert.True(t, a.Required("POST /events"))
+
+	disabled, err := New(config.AuthConf{})
+	require.NoError(t, err)
+	assert.False(t, disabled.Required("POST /events"))
+}
+
+func TestParseAuthorization(t *testing.T) {
+	assert.Equal(t, "abc", ParseAuthorization("Bearer abc"))
+	assert.Equal(t, "abc", ParseAuthorization("bearer abc"))
+	assert.Equal(t, "", ParseAuthorization("Basic abc"))
+	assert.Equal(t, "", ParseAuthorization(""))
+}
@@ -0,0 +1,15 @@
+package auth
+
+import "context"
+
+type userIDKey struct{}
+
+// WithUserID сохраняет в контексте идентификатор пользователя, от имени которого выполняется запрос.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+func UserID(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int)
+	return userID, ok
+}
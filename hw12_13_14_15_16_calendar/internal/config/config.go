@@ -2,48 +2,340 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Logger     LoggerConf
-	Server     ServerConf
-	Storage    StorageConf
-	Migrations MigrationsConf
+	Logger      LoggerConf
+	Server      ServerConf
+	Storage     StorageConf
+	Migrations  MigrationsConf
+	Webhooks    WebhooksConf
+	Notifier    NotifierConf
+	Scheduler   SchedulerConf
+	Tracing     TracingConf
+	CORS        CORSConf
+	Shutdown    ShutdownConf
+	RateLimit   RateLimitConf
+	Auth        AuthConf
+	Idempotency IdempotencyConf
+	Outbox      OutboxConf
 }
 
 type LoggerConf struct {
 	Level    string
 	FileName string
+	Format   string
+	Output   string
+	// Ротация файла: размер в мегабайтах, возраст в днях, число старых файлов.
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	Compress   bool
 }
 
 type ServerConf struct {
-	Host string
-	Port string
+	Host        string
+	Port        string
+	GrpcPort    string
+	MetricsAddr string
+	// TrustedProxies — адреса и подсети (CIDR) прокси, от которых принимаются
+	// X-Real-IP и X-Forwarded-For. У остальных клиентом считается адрес соединения.
+	TrustedProxies []string
 }
 
 type StorageConf struct {
-	Dsn         string
+	Dsn string
+	// ReplicaDsn — необязательная реплика, на которую уходит чтение событий и вебхуков.
+	ReplicaDsn  string
 	StorageType string
+	Pool        PoolConf
+	Cache       CacheConf
+}
+
+// PoolConf настраивает пулы соединений с основной базой и репликой; нули снимают ограничения.
+type PoolConf struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration
+}
+
+// CacheConf включает LRU-кеш чтения событий перед хранилищем.
+type CacheConf struct {
+	Enabled bool
+	// Size — максимальное число закешированных событий и списков.
+	Size int
+	TTL  time.Duration
 }
 
 type MigrationsConf struct {
 	AutoMigrate bool
-	Dir         string
+	// Dir — каталог исходников миграций для `calendar migrate create`.
+	// Применяются всегда миграции, вшитые в бинарник.
+	Dir string
+}
+
+type WebhooksConf struct {
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AllowPrivateNetworks разрешает доставку на частные, loopback и link-local адреса,
+	// например приёмнику на той же машине при разработке.
+	AllowPrivateNetworks bool
+}
+
+type NotifierConf struct {
+	Template string
+	SMTP     SMTPConf
+	HTTP     HTTPNotifierConf
+}
+
+type SMTPConf struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type HTTPNotifierConf struct {
+	Timeout time.Duration
+}
+
+type SchedulerConf struct {
+	Interval time.Duration
+}
+
+type TracingConf struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+type CORSConf struct {
+	// AllowedOrigins — список разрешённых Origin, "*" разрешает любой.
+	AllowedOrigins []string
+}
+
+// RateLimitConf задаёт лимиты для групп маршрутов HTTP API.
+type RateLimitConf struct {
+	Enabled  bool
+	Events   RouteLimitConf
+	Webhooks RouteLimitConf
+	Channels RouteLimitConf
+}
+
+type RouteLimitConf struct {
+	RequestsPerSecond float64
+	Burst             int
+	// MaxBodyBytes ограничивает размер тела запроса, 0 — без ограничения.
+	MaxBodyBytes int64
+}
+
+// IdempotencyConf задаёт, сколько хранятся ответы на запросы с Idempotency-Key.
+type IdempotencyConf struct {
+	TTL time.Duration
+	// LockTimeout — сколько ключ занят выполняющимся запросом. Если процесс упадёт,
+	// не дописав ответ, ключ освободится через это время, а не через весь TTL.
+	LockTimeout time.Duration
+	// RequestTimeout ограничивает выполнение запроса с ключом. Должен быть меньше LockTimeout,
+	// иначе ключ ещё выполняющегося запроса сможет занять повтор.
+	RequestTimeout time.Duration
+	// CleanupInterval — период удаления просроченных ключей.
+	CleanupInterval time.Duration
+}
+
+// OutboxConf настраивает публикацию сообщений outbox в очередь.
+type OutboxConf struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Retention — сколько хранятся опубликованные сообщения, CleanupInterval — период их удаления.
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// MaxAttempts — сколько раз получатель пытается обработать сообщение, паузы между
+	// попытками растут от InitialBackoff до MaxBackoff.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// ClaimTimeout — на сколько relay захватывает пачку сообщений. Должен быть больше
+	// времени публикации пачки, иначе её подхватит другой экземпляр.
+	ClaimTimeout time.Duration
+}
+
+type ShutdownConf struct {
+	// Timeout — общий срок на остановку всех компонентов.
+	Timeout time.Duration
+	// DrainDelay — пауза между переводом readiness в fail и остановкой серверов,
+	// за которую балансировщик успевает убрать экземпляр из ротации. Входит в Timeout.
+	DrainDelay time.Duration
+}
+
+// AuthConf включает аутентификацию запросов по JWT или статическим API-ключам.
+type AuthConf struct {
+	Enabled bool
+	JWT     JWTConf
+	APIKeys []APIKeyConf
+	// PublicRoutes — маршруты без аутентификации: шаблоны HTTP ("GET /healthz")
+	// и полные имена методов gRPC ("/grpc.health.v1.Health/Check").
+	PublicRoutes []string
 }
 
+type JWTConf struct {
+	// Issuer и Audience проверяются, только если заданы.
+	Issuer   string
+	Audience string
+	Keys     []JWTKeyConf
+}
+
+type JWTKeyConf struct {
+	// ID сопоставляется с заголовком kid токена; токен без kid проверяется всеми ключами.
+	ID            string
+	Algorithm     string
+	Secret        string
+	PublicKeyFile string
+}
+
+type APIKeyConf struct {
+	Key    string
+	UserID int
+}
+
+const envPrefix = "CALENDAR"
+
+// LoadConfig читает файл конфигурации поверх значений по умолчанию, затем применяет
+// переменные окружения вида CALENDAR_STORAGE_DSN. Пустой путь означает «без файла».
 func LoadConfig(configPath string) (*Config, error) {
-	viper.SetConfigFile(configPath)
+	v := viper.New()
+	setDefaults(v)
 
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	if err := bindEnvs(v, reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, err
+	}
+
+	if configPath != "" {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
 	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
 	return &config, nil
 }
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("logger.level", "INFO")
+	v.SetDefault("logger.filename", "logs/app.log")
+	v.SetDefault("logger.format", "text")
+	v.SetDefault("logger.output", "stdout")
+	v.SetDefault("logger.maxsize", 100)
+	v.SetDefault("logger.maxage", 7)
+	v.SetDefault("logger.maxbackups", 5)
+	v.SetDefault("logger.compress", true)
+
+	v.SetDefault("server.host", "0.0.0.0")
+	v.SetDefault("server.port", "8080")
+	v.SetDefault("server.grpcport", "50051")
+
+	v.SetDefault("storage.storagetype", "memory")
+	v.SetDefault("storage.pool.maxopenconns", 20)
+	v.SetDefault("storage.pool.maxidleconns", 5)
+	v.SetDefault("storage.pool.connmaxlifetime", "30m")
+	v.SetDefault("storage.pool.connmaxidletime", "5m")
+	v.SetDefault("storage.pool.statementtimeout", "30s")
+	v.SetDefault("storage.cache.enabled", false)
+	v.SetDefault("storage.cache.size", 1000)
+	v.SetDefault("storage.cache.ttl", "1m")
+
+	v.SetDefault("migrations.automigrate", false)
+	v.SetDefault("migrations.dir", "migrations")
+
+	v.SetDefault("webhooks.timeout", "5s")
+	v.SetDefault("webhooks.maxattempts", 5)
+	v.SetDefault("webhooks.initialbackoff", "1s")
+	v.SetDefault("webhooks.maxbackoff", "1m")
+
+	v.SetDefault("notifier.smtp.port", "25")
+	v.SetDefault("notifier.smtp.from", "calendar@localhost")
+	v.SetDefault("notifier.http.timeout", "5s")
+
+	v.SetDefault("scheduler.interval", "1m")
+
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.servicename", "calendar")
+	v.SetDefault("tracing.sampleratio", 1.0)
+
+	v.SetDefault("shutdown.timeout", "10s")
+	v.SetDefault("shutdown.draindelay", "5s")
+
+	v.SetDefault("ratelimit.enabled", true)
+	for _, group := range []string{"events", "webhooks", "channels"} {
+		v.SetDefault("ratelimit."+group+".requestspersecond", 10)
+		v.SetDefault("ratelimit."+group+".burst", 20)
+		v.SetDefault("ratelimit."+group+".maxbodybytes", 1<<20)
+	}
+
+	v.SetDefault("idempotency.ttl", "24h")
+	v.SetDefault("idempotency.locktimeout", "2m")
+	v.SetDefault("idempotency.requesttimeout", "30s")
+	v.SetDefault("idempotency.cleanupinterval", "10m")
+
+	v.SetDefault("outbox.pollinterval", "1s")
+	v.SetDefault("outbox.batchsize", 100)
+	v.SetDefault("outbox.retention", "24h")
+	v.SetDefault("outbox.cleanupinterval", "10m")
+	v.SetDefault("outbox.maxattempts", 5)
+	v.SetDefault("outbox.initialbackoff", "1s")
+	v.SetDefault("outbox.maxbackoff", "1m")
+	v.SetDefault("outbox.claimtimeout", "5m")
+
+	v.SetDefault("auth.enabled", false)
+	v.SetDefault("auth.publicroutes", []string{
+		"GET /healthz",
+		"GET /readyz",
+		"GET /metrics",
+		"GET /openapi.json",
+		"GET /docs/",
+		"/grpc.health.v1.Health/Check",
+		"/grpc.health.v1.Health/Watch",
+	})
+}
+
+// bindEnvs регистрирует в viper все поля конфигурации, чтобы каждое из них
+// можно было переопределить переменной окружения, даже если его нет в файле.
+func bindEnvs(v *viper.Viper, t reflect.Type, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.ToLower(field.Name)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := bindEnvs(v, field.Type, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := v.BindEnv(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
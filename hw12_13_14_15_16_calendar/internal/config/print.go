@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretMask совпадает с маской, которую использует url.URL.Redacted.
+const secretMask = "xxxxx"
+
+var dsnPasswordRe = regexp.MustCompile(`(password=)(\S+)`)
+
+// Masked возвращает копию конфигурации, в которой скрыты пароли и ключи.
+func (c Config) Masked() Config {
+	c.Storage.Dsn = maskDSN(c.Storage.Dsn)
+	c.Storage.ReplicaDsn = maskDSN(c.Storage.ReplicaDsn)
+	if c.Notifier.SMTP.Password != "" {
+		c.Notifier.SMTP.Password = secretMask
+	}
+
+	// Срезы копируются, чтобы не испортить исходную конфигурацию.
+	c.Auth.JWT.Keys = append([]JWTKeyConf(nil), c.Auth.JWT.Keys...)
+	for i := range c.Auth.JWT.Keys {
+		if c.Auth.JWT.Keys[i].Secret != "" {
+			c.Auth.JWT.Keys[i].Secret = secretMask
+		}
+	}
+	c.Auth.APIKeys = append([]APIKeyConf(nil), c.Auth.APIKeys...)
+	for i := range c.Auth.APIKeys {
+		c.Auth.APIKeys[i].Key = secretMask
+	}
+	return c
+}
+
+func maskDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	// DSN в формате key=value.
+	return dsnPasswordRe.ReplaceAllString(dsn, "${1}"+secretMask)
+}
+
+// WriteTOML выводит конфигурацию в формате файла config.toml.
+func (c Config) WriteTOML(w io.Writer) error {
+	return writeSection(w, reflect.ValueOf(c), "")
+}
+
+func writeSection(w io.Writer, v reflect.Value, name string) error {
+	t := v.Type()
+	var nested []int
+
+	if name != "" {
+		if _, err := fmt.Fprintf(w, "[%s]\n", name); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct || isStructSlice(field) {
+			nested = append(nested, i)
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s\n", t.Field(i).Name, formatValue(field)); err != nil {
+			return err
+		}
+	}
+
+	for n, i := range nested {
+		if name != "" || n > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		section := t.Field(i).Name
+		if name != "" {
+			section = name + "." + section
+		}
+		if isStructSlice(v.Field(i)) {
+			if err := writeTables(w, v.Field(i), section); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeSection(w, v.Field(i), section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTables выводит срез структур как массив таблиц [[name]].
+func writeTables(w io.Writer, v reflect.Value, name string) error {
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "[[%s]]\n", name); err != nil {
+			return err
+		}
+		item := v.Index(i)
+		for j := 0; j < item.NumField(); j++ {
+			if _, err := fmt.Fprintf(w, "%s = %s\n", item.Type().Field(j).Name, formatValue(item.Field(j))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isStructSlice(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct
+}
+
+func formatValue(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return strconv.Quote(d.String())
+	}
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce склеивает серию событий файловой системы от одного сохранения файла.
+const reloadDebounce = 200 * time.Millisecond
+
+type Logger interface {
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+}
+
+// ApplyFunc применяет к работающему сервису настройки, которые можно менять на лету.
+type ApplyFunc func(conf *Config) error
+
+// Reloader перечитывает конфигурацию и применяет изменения без перезапуска.
+// Изменения настроек, требующих перезапуска, только сообщаются в лог.
+type Reloader struct {
+	mu       sync.Mutex
+	path     string
+	logger   Logger
+	current  *Config
+	appliers []ApplyFunc
+}
+
+func NewReloader(logger Logger, path string, current *Config) *Reloader {
+	return &Reloader{logger: logger, path: path, current: current}
+}
+
+func (r *Reloader) OnReload(fn ApplyFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, fn)
+}
+
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload перечитывает файл и переменные окружения. Невалидная конфигурация отклоняется целиком.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := LoadConfig(r.path)
+	if err != nil {
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	if changed := RestartRequired(r.current, next); len(changed) > 0 {
+		r.logger.Warn(fmt.Sprintf("config settings require restart to take effect: %s",
+			strings.Join(changed, ", ")))
+	}
+
+	var errs []string
+	for _, apply := range r.appliers {
+		if err := apply(next); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// Остальные секции остаются прежними, чтобы Current отражал фактическое состояние.
+	applied := *r.current
+	copyReloadable(&applied, next)
+	r.current = &applied
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to apply config: %s", strings.Join(errs, "; "))
+	}
+
+	r.logger.Info("config reloaded")
+	return nil
+}
+
+// Watch следит за файлом конфигурации до отмены ctx. Отслеживается каталог,
+// так как редакторы и ConfigMap в Kubernetes подменяют файл переименованием.
+func (r *Reloader) Watch(ctx context.Context) error {
+	if r.path == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+		return err
+	}
+
+	name := filepath.Clean(r.path)
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == name && !event.Has(fsnotify.Chmod) {
+				debounce = time.After(reloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.logger.Error(fmt.Sprintf("config watcher error: %v", err))
+		case <-debounce:
+			debounce = nil
+			if err := r.Reload(); err != nil {
+				r.logger.Error(fmt.Sprintf("failed to reload config: %v", err))
+			}
+		}
+	}
+}
+
+// RestartRequired возвращает ключи изменившихся настроек, которые применяются только при старте.
+func RestartRequired(old, next *Config) []string {
+	nextCopy := *next
+	copyReloadable(&nextCopy, old)
+
+	var changed []string
+	diffFields(reflect.ValueOf(*old), reflect.ValueOf(nextCopy), "", &changed)
+	return changed
+}
+
+// copyReloadable переносит из src настройки, которые применяются на лету.
+func copyReloadable(dst, src *Config) {
+	dst.Logger.Level = src.Logger.Level
+	dst.Scheduler = src.Scheduler
+	dst.CORS = src.CORS
+	dst.RateLimit = src.RateLimit
+}
+
+func diffFields(old, next reflect.Value, prefix string, changed *[]string) {
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.ToLower(t.Field(i).Name)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if old.Field(i).Kind() == reflect.Struct {
+			diffFields(old.Field(i), next.Field(i), key, changed)
+			continue
+		}
+		if !reflect.DeepEqual(old.Field(i).Interface(), next.Field(i).Interface()) {
+			*changed = append(*changed, key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Warn(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+
+func TestReloader_AppliesReloadableSettings(t *testing.T) {
+	path := writeConfig(t, `
+[Logger]
+Level = "INFO"
+
+[Server]
+Port = "8080"
+`)
+
+	current, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	var level string
+	reloader := NewReloader(nopLogger{}, path, current)
+	reloader.OnReload(func(conf *Config) error {
+		level = conf.Logger.Level
+		return nil
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+[Logger]
+Level = "DEBUG"
+
+[Server]
+Port = "9090"
+`), 0o600))
+	require.NoError(t, reloader.Reload())
+
+	assert.Equal(t, "DEBUG", level)
+	assert.Equal(t, "DEBUG", reloader.Current().Logger.Level)
+	// Порт меняется только после перезапуска.
+	assert.Equal(t, "8080", reloader.Current().Server.Port)
+
+	next, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"server.port"}, RestartRequired(current, next))
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	path := writeConfig(t, `[Logger]
+Level = "INFO"
+`)
+
+	current, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	var calls int
+	reloader := NewReloader(nopLogger{}, path, current)
+	reloader.OnReload(func(*Config) error {
+		calls++
+		return nil
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(`[Logger]
+Level = "LOUD"
+`), 0o600))
+	require.Error(t, reloader.Reload())
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "INFO", reloader.Current().Logger.Level)
+}
+
+func TestReloader_WatchesFile(t *testing.T) {
+	path := writeConfig(t, `[Scheduler]
+Interval = "1m"
+`)
+
+	current, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	var interval atomic.Int64
+	reloader := NewReloader(nopLogger{}, path, current)
+	reloader.OnReload(func(conf *Config) error {
+		interval.Store(int64(conf.Scheduler.Interval))
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = reloader.Watch(ctx) }()
+
+	// Даём watcher'у подписаться на каталог.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte(`[Scheduler]
+Interval = "30s"
+`), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return time.Duration(interval.Load()) == 30*time.Second
+	}, 3*time.Second, 50*time.Millisecond)
+}
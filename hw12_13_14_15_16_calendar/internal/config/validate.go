@@ -0,0 +1,305 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все найденные ошибки сразу.
+func (c *Config) Validate() error {
+	var errs validationErrors
+
+	c.validateLogger(&errs)
+	c.validateServer(&errs)
+	c.validateStorage(&errs)
+	c.validateDelivery(&errs)
+	c.validateTracing(&errs)
+	c.validateCORS(&errs)
+	c.validateRateLimit(&errs)
+	c.validateAuth(&errs)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
+
+type validationErrors []error
+
+func (e *validationErrors) add(format string, args ...interface{}) {
+	*e = append(*e, fmt.Errorf(format, args...))
+}
+
+func (c *Config) validateLogger(errs *validationErrors) {
+	add := errs.add
+
+	switch strings.ToLower(c.Logger.Level) {
+	case "panic", "fatal", "error", "warn", "warning", "info", "debug", "trace":
+	default:
+		add("logger.level: unknown level %q", c.Logger.Level)
+	}
+	if !oneOf(c.Logger.Format, "text", "json") {
+		add("logger.format: must be text or json, got %q", c.Logger.Format)
+	}
+	if !oneOf(c.Logger.Output, "stdout", "file", "both") {
+		add("logger.output: must be stdout, file or both, got %q", c.Logger.Output)
+	} else if c.Logger.Output != "stdout" && c.Logger.FileName == "" {
+		add("logger.filename: required for output %q", c.Logger.Output)
+	}
+}
+
+func (c *Config) validateServer(errs *validationErrors) {
+	add := errs.add
+
+	if err := validatePort(c.Server.Port); err != nil {
+		add("server.port: %v", err)
+	}
+	if err := validatePort(c.Server.GrpcPort); err != nil {
+		add("server.grpcport: %v", err)
+	}
+	if c.Server.MetricsAddr != "" {
+		if _, port, err := net.SplitHostPort(c.Server.MetricsAddr); err != nil {
+			add("server.metricsaddr: %v", err)
+		} else if err := validatePort(port); err != nil {
+			add("server.metricsaddr: %v", err)
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !validProxy(proxy) {
+			add("server.trustedproxies: %q is not an IP address or CIDR", proxy)
+		}
+	}
+}
+
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, err := netip.ParsePrefix(proxy)
+		return err == nil
+	}
+	_, err := netip.ParseAddr(proxy)
+	return err == nil
+}
+
+func (c *Config) validateStorage(errs *validationErrors) {
+	add := errs.add
+
+	switch c.Storage.StorageType {
+	case "memory":
+	case "postgres":
+		if c.Storage.Dsn == "" {
+			add("storage.dsn: required for postgres storage")
+		}
+	default:
+		add("storage.storagetype: must be memory or postgres, got %q", c.Storage.StorageType)
+	}
+
+	if c.Storage.ReplicaDsn != "" && c.Storage.StorageType != "postgres" {
+		add("storage.replicadsn: only supported for postgres storage")
+	}
+
+	pool := c.Storage.Pool
+	if pool.MaxOpenConns < 0 {
+		add("storage.pool.maxopenconns: must not be negative")
+	}
+	if pool.MaxIdleConns < 0 {
+		add("storage.pool.maxidleconns: must not be negative")
+	}
+	if pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		add("storage.pool.maxidleconns: must not exceed maxopenconns")
+	}
+	if pool.ConnMaxLifetime < 0 {
+		add("storage.pool.connmaxlifetime: must not be negative")
+	}
+	if pool.ConnMaxIdleTime < 0 {
+		add("storage.pool.connmaxidletime: must not be negative")
+	}
+	if pool.StatementTimeout < 0 {
+		add("storage.pool.statementtimeout: must not be negative")
+	}
+
+	if c.Storage.Cache.Enabled {
+		if c.Storage.Cache.Size <= 0 {
+			add("storage.cache.size: must be positive")
+		}
+		if c.Storage.Cache.TTL <= 0 {
+			add("storage.cache.ttl: must be positive")
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		add("idempotency.ttl: must be positive")
+	}
+	if c.Idempotency.CleanupInterval <= 0 {
+		add("idempotency.cleanupinterval: must be positive")
+	}
+	if c.Idempotency.RequestTimeout <= 0 {
+		add("idempotency.requesttimeout: must be positive")
+	}
+	if c.Idempotency.LockTimeout <= c.Idempotency.RequestTimeout {
+		add("idempotency.locktimeout: must be greater than requesttimeout")
+	}
+
+	if c.Outbox.PollInterval <= 0 {
+		add("outbox.pollinterval: must be positive")
+	}
+	if c.Outbox.BatchSize <= 0 {
+		add("outbox.batchsize: must be positive")
+	}
+	if c.Outbox.Retention <= 0 {
+		add("outbox.retention: must be positive")
+	}
+	if c.Outbox.CleanupInterval <= 0 {
+		add("outbox.cleanupinterval: must be positive")
+	}
+	if c.Outbox.MaxAttempts < 1 {
+		add("outbox.maxattempts: must be at least 1, got %d", c.Outbox.MaxAttempts)
+	}
+	if c.Outbox.InitialBackoff <= 0 {
+		add("outbox.initialbackoff: must be positive")
+	}
+	if c.Outbox.MaxBackoff < c.Outbox.InitialBackoff {
+		add("outbox.maxbackoff: must not be less than initialbackoff")
+	}
+	if c.Outbox.ClaimTimeout <= 0 {
+		add("outbox.claimtimeout: must be positive")
+	}
+}
+
+// validateDelivery проверяет настройки вебхуков, уведомлений и планировщика.
+func (c *Config) validateDelivery(errs *validationErrors) {
+	add := errs.add
+
+	if c.Webhooks.Timeout < 0 {
+		add("webhooks.timeout: must not be negative")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		add("webhooks.maxattempts: must be at least 1, got %d", c.Webhooks.MaxAttempts)
+	}
+	if c.Webhooks.InitialBackoff <= 0 {
+		add("webhooks.initialbackoff: must be positive")
+	}
+	if c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+		add("webhooks.maxbackoff: must not be less than initialbackoff")
+	}
+
+	if err := validatePort(c.Notifier.SMTP.Port); err != nil {
+		add("notifier.smtp.port: %v", err)
+	}
+	if c.Notifier.HTTP.Timeout < 0 {
+		add("notifier.http.timeout: must not be negative")
+	}
+
+	if c.Scheduler.Interval <= 0 {
+		add("scheduler.interval: must be positive")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		add("shutdown.timeout: must be positive")
+	}
+	if c.Shutdown.DrainDelay < 0 || c.Shutdown.DrainDelay >= c.Shutdown.Timeout {
+		add("shutdown.draindelay: must be non-negative and less than shutdown.timeout")
+	}
+}
+
+func (c *Config) validateTracing(errs *validationErrors) {
+	add := errs.add
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			add("tracing.endpoint: required for otlp exporter")
+		}
+	default:
+		add("tracing.exporter: must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sampleratio: must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+}
+
+func (c *Config) validateCORS(errs *validationErrors) {
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || u.Path != "" {
+			errs.add("cors.allowedorigins: invalid origin %q", origin)
+		}
+	}
+}
+
+func (c *Config) validateRateLimit(errs *validationErrors) {
+	groups := []struct {
+		name  string
+		limit RouteLimitConf
+	}{
+		{"events", c.RateLimit.Events},
+		{"webhooks", c.RateLimit.Webhooks},
+		{"channels", c.RateLimit.Channels},
+	}
+	for _, g := range groups {
+		name, group := g.name, g.limit
+		if c.RateLimit.Enabled && (group.RequestsPerSecond <= 0 || group.Burst < 1) {
+			errs.add("ratelimit.%s: requestspersecond must be positive and burst at least 1", name)
+		}
+		if group.MaxBodyBytes < 0 {
+			errs.add("ratelimit.%s.maxbodybytes: must not be negative", name)
+		}
+	}
+}
+
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("port must be numeric, got %q", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("port must be in range 1-65535, got %d", n)
+	}
+	return nil
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) validateAuth(errs *validationErrors) {
+	if !c.Auth.Enabled {
+		return
+	}
+	if len(c.Auth.JWT.Keys) == 0 && len(c.Auth.APIKeys) == 0 {
+		errs.add("auth: at least one jwt key or api key is required")
+	}
+
+	for i, key := range c.Auth.JWT.Keys {
+		switch key.Algorithm {
+		case "HS256":
+			if key.Secret == "" {
+				errs.add("auth.jwt.keys[%d].secret: required for HS256", i)
+			}
+		case "RS256":
+			if key.PublicKeyFile == "" {
+				errs.add("auth.jwt.keys[%d].publickeyfile: required for RS256", i)
+			}
+		default:
+			errs.add("auth.jwt.keys[%d].algorithm: must be HS256 or RS256, got %q", i, key.Algorithm)
+		}
+	}
+
+	for i, key := range c.Auth.APIKeys {
+		if key.Key == "" || key.UserID <= 0 {
+			errs.add("auth.apikeys[%d]: key and positive userid are required", i)
+		}
+	}
+}
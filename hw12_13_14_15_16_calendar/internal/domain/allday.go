@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// DateLayout — формат дат событий на весь день.
+const DateLayout = "2006-01-02"
+
+// Date возвращает полночь UTC календарного дня t в его собственном часовом поясе.
+// Так хранятся дни событий на весь день: дата не зависит от пояса, в котором её смотрят.
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate разбирает дату в формате YYYY-MM-DD.
+func ParseDate(s string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return date, nil
+}
+
+// SetDates делает событие событием на весь день с first по last включительно.
+func (e *Event) SetDates(first, last time.Time) error {
+	first, last = Date(first), Date(last)
+	if last.Before(first) {
+		return ErrInvalidDateRange
+	}
+
+	e.AllDay = true
+	e.EventTime = first
+	e.Duration = last.Sub(first) + day
+	return nil
+}
+
+// SetDateStrings — SetDates для дат в формате DateLayout; пустой end означает однодневное событие.
+func (e *Event) SetDateStrings(start, end string) error {
+	first, err := ParseDate(start)
+	if err != nil {
+		return err
+	}
+	last := first
+	if end != "" {
+		if last, err = ParseDate(end); err != nil {
+			return err
+		}
+	}
+	return e.SetDates(first, last)
+}
+
+// Dates возвращает первый и последний день события на весь день.
+func (e *Event) Dates() (first, last time.Time) {
+	return e.EventTime, e.EventTime.Add(e.Duration - day)
+}
+
+// Within сообщает, относится ли событие к интервалу [start, end). Обычное событие относится
+// к нему по времени начала, событие на весь день — если хотя бы один его день попадает
+// в дни интервала по календарю часового пояса start.
+func (e *Event) Within(start, end time.Time) bool {
+	if !e.AllDay {
+		return !e.EventTime.Before(start) && e.EventTime.Before(end)
+	}
+	return e.EventTime.Before(Date(end)) && e.GetEndTime().After(Date(start))
+}
+
+// validateAllDay приводит начало к полуночи UTC; без длительности событие длится один день.
+func (e *Event) validateAllDay() error {
+	e.EventTime = Date(e.EventTime)
+	if e.Duration == 0 {
+		e.Duration = day
+	}
+	if e.Duration < 0 || e.Duration%day != 0 {
+		return ErrInvalidAllDayDuration
+	}
+	return nil
+}
+
+var (
+	ErrInvalidDate           = errors.New("date must be in YYYY-MM-DD format")
+	ErrInvalidDateRange      = errors.New("end date must not be before start date")
+	ErrInvalidAllDayDuration = errors.New("all-day event duration must be a whole number of days")
+)
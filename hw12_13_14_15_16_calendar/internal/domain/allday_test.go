@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent_AllDay(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	losAngeles := time.FixedZone("PST", -8*60*60)
+
+	// Отпуск с 10 по 12 ноября включительно.
+	var vacation Event
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"title":"Vacation","allDay":true,"startDate":"2025-11-10","endDate":"2025-11-12"}`), &vacation))
+	require.NoError(t, vacation.Validate())
+	assert.Equal(t, time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC), vacation.EventTime)
+	assert.Equal(t, 3*day, vacation.Duration)
+
+	data, err := json.Marshal(vacation)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "2025-11-10", out["startDate"])
+	assert.Equal(t, "2025-11-12", out["endDate"])
+	assert.Equal(t, "P3D", out["duration"])
+
+	for _, loc := range []*time.Location{time.UTC, moscow, losAngeles} {
+		dayStart := func(d int) time.Time { return time.Date(2025, 11, d, 0, 0, 0, 0, loc) }
+
+		assert.False(t, vacation.Within(dayStart(9), dayStart(10)), loc)
+		for d := 10; d <= 12; d++ {
+			assert.True(t, vacation.Within(dayStart(d), dayStart(d+1)), "%s %d", loc, d)
+		}
+		assert.False(t, vacation.Within(dayStart(13), dayStart(14)), loc)
+		assert.True(t, vacation.Within(dayStart(3), dayStart(10).AddDate(0, 0, 1)), loc)
+	}
+
+	// Дата берётся из пояса, в котором задано время, а не из UTC.
+	birthday := Event{Title: "Birthday", AllDay: true, EventTime: time.Date(2025, 11, 5, 0, 30, 0, 0, moscow)}
+	require.NoError(t, birthday.Validate())
+	first, last := birthday.Dates()
+	assert.Equal(t, time.Date(2025, 11, 5, 0, 0, 0, 0, time.UTC), first)
+	assert.Equal(t, first, last)
+
+	bad := Event{Title: "Bad", AllDay: true, EventTime: first, Duration: 36 * time.Hour}
+	assert.ErrorIs(t, bad.Validate(), ErrInvalidAllDayDuration)
+	assert.ErrorIs(t, bad.SetDates(first, first.AddDate(0, 0, -1)), ErrInvalidDateRange)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"allDay":true,"startDate":"05.11.2025"}`), &bad), ErrInvalidDate)
+}
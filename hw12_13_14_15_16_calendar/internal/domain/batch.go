@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+type BatchMode string
+
+const (
+	// BatchAllOrNothing применяет пакет, только если корректны все его элементы.
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort применяет корректные элементы и пропускает ошибочные.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+const MaxBatchSize = 1000
+
+// ParseBatchMode по умолчанию выбирает режим «всё или ничего».
+func ParseBatchMode(s string) (BatchMode, error) {
+	switch mode := BatchMode(s); mode {
+	case "":
+		return BatchAllOrNothing, nil
+	case BatchAllOrNothing, BatchBestEffort:
+		return mode, nil
+	default:
+		return "", ErrInvalidBatchMode
+	}
+}
+
+func ValidateBatchSize(n int) error {
+	if n == 0 {
+		return ErrEmptyBatch
+	}
+	if n > MaxBatchSize {
+		return ErrBatchTooLarge
+	}
+	return nil
+}
+
+// BatchFailed сообщает, отклонён ли хотя бы один элемент пакета.
+func BatchFailed(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AbortBatch помечает элементы без собственной ошибки как неприменённые из-за соседних.
+func AbortBatch(errs []error) {
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = ErrBatchAborted
+		}
+	}
+}
+
+var (
+	ErrInvalidBatchMode = errors.New("batch mode must be all_or_nothing or best_effort")
+	ErrEmptyBatch       = errors.New("batch must not be empty")
+	ErrBatchTooLarge    = fmt.Errorf("batch must not contain more than %d items", MaxBatchSize)
+	ErrBatchAborted     = errors.New("not applied because another item of the batch failed")
+)
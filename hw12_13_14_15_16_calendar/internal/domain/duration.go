@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidISODuration = errors.New("duration must be in ISO 8601 format, e.g. PT1H30M")
+
+const day = 24 * time.Hour
+
+// FormatISODuration записывает длительность в формате ISO 8601 (P1DT2H30M).
+// Сутки считаются равными 24 часам, годы и месяцы не используются.
+func FormatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+
+	if days := d / day; days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * day
+	}
+	if d == 0 {
+		return b.String()
+	}
+
+	b.WriteByte('T')
+	if hours := d / time.Hour; hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+		d -= minutes * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// ParseISODuration разбирает длительность вида PnW, PnDTnHnMnS. Годы и месяцы
+// не поддерживаются: их длина зависит от даты.
+func ParseISODuration(s string) (time.Duration, error) {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	rest, ok := strings.CutPrefix(s, "P")
+	if !ok || rest == "" {
+		return 0, ErrInvalidISODuration
+	}
+
+	datePart, timePart, hasTime := strings.Cut(rest, "T")
+	if hasTime && timePart == "" {
+		return 0, ErrInvalidISODuration
+	}
+
+	total, err := sumComponents(datePart, map[byte]time.Duration{'W': 7 * day, 'D': day}, "WD")
+	if err != nil {
+		return 0, err
+	}
+	timeTotal, err := sumComponents(timePart, map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+	}, "HMS")
+	if err != nil {
+		return 0, err
+	}
+
+	total += timeTotal
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// sumComponents складывает компоненты вида 12H, идущие в порядке order.
+func sumComponents(s string, units map[byte]time.Duration, order string) (time.Duration, error) {
+	var total time.Duration
+	last := -1
+
+	for s != "" {
+		i := strings.IndexAny(s, order)
+		if i <= 0 {
+			return 0, ErrInvalidISODuration
+		}
+
+		pos := strings.IndexByte(order, s[i])
+		if pos <= last {
+			return 0, ErrInvalidISODuration
+		}
+		last = pos
+
+		value, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil || value < 0 {
+			return 0, ErrInvalidISODuration
+		}
+		total += time.Duration(value * float64(units[s[i]]))
+		s = s[i+1:]
+	}
+	return total, nil
+}
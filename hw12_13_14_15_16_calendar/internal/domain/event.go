@@ -5,20 +5,31 @@ import (
 	"time"
 )
 
+// Event сериализуется в JSON через MarshalJSON, см. json.go.
 type Event struct {
-	ID           int           `json:"id"`
-	Title        string        `json:"title"`
-	EventTime    time.Time     `json:"-"`
-	Duration     time.Duration `json:"-"`
-	Description  string        `json:"description"`
-	UserID       int           `json:"-"`
-	TimeToNotify time.Time     `json:"-"`
+	ID          int
+	Title       string
+	EventTime   time.Time
+	Duration    time.Duration
+	Description string
+	UserID      int
+	// TimeToNotify — время самого раннего напоминания; вычисляется в Validate, от клиента не принимается.
+	TimeToNotify time.Time
+	Reminders    []Reminder
+	// Category — одна метка события, Tags — произвольные, Color — цвет в календаре (#rrggbb).
+	Category string
+	Color    string
+	Tags     []string
+	// AllDay — событие на весь день или несколько дней: EventTime — полночь UTC первого дня,
+	// Duration — целое число дней, см. allday.go.
+	AllDay bool
 }
 
 func (e *Event) GetEndTime() time.Time {
 	return e.EventTime.Add(e.Duration)
 }
 
+// Validate заодно приводит теги, категорию и цвет к каноническому виду и вычисляет TimeToNotify.
 func (e *Event) Validate() error {
 	if e.Title == "" {
 		return ErrEmptyTitle
@@ -26,10 +37,23 @@ func (e *Event) Validate() error {
 	if e.EventTime.IsZero() {
 		return ErrInvalidEventTime
 	}
-	if e.Duration <= 0 {
+	if e.AllDay {
+		if err := e.validateAllDay(); err != nil {
+			return err
+		}
+	} else if e.Duration <= 0 {
 		return ErrInvalidDuration
 	}
-	return nil
+	e.TimeToNotify = time.Time{}
+	for i := range e.Reminders {
+		if err := e.Reminders[i].Validate(); err != nil {
+			return err
+		}
+		if fireAt := e.EventTime.Add(-e.Reminders[i].Offset); e.TimeToNotify.IsZero() || fireAt.Before(e.TimeToNotify) {
+			e.TimeToNotify = fireAt
+		}
+	}
+	return e.normalizeLabels()
 }
 
 var (
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord запоминает результат запроса с ключом идемпотентности.
+// Пока запрос выполняется, Response равен nil.
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	RequestHash string
+	// Owner — случайный токен запроса, занявшего ключ.
+	Owner     string
+	Response  []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Response != nil
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	for _, c := range key {
+		if c < 0x21 || c > 0x7e {
+			return ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
+
+var (
+	ErrInvalidIdempotencyKey  = errors.New("idempotency key must be 1-255 printable ASCII characters")
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInProgress  = errors.New("request with this idempotency key is still in progress")
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+)
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// eventJSON — представление события в API: время в RFC 3339, длительность в ISO 8601.
+// Дни события на весь день передаются датами startDate и endDate (включительно).
+type eventJSON struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	EventTime    time.Time  `json:"eventTime"`
+	Duration     string     `json:"duration"`
+	Description  string     `json:"description"`
+	UserID       int        `json:"userId"`
+	TimeToNotify *time.Time `json:"timeToNotify,omitempty"`
+	Reminders    []Reminder `json:"reminders"`
+	Category     string     `json:"category"`
+	Color        string     `json:"color"`
+	Tags         []string   `json:"tags"`
+	AllDay       bool       `json:"allDay"`
+	StartDate    string     `json:"startDate,omitempty"`
+	EndDate      string     `json:"endDate,omitempty"`
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	out := eventJSON{
+		ID:          e.ID,
+		Title:       e.Title,
+		EventTime:   e.EventTime,
+		Duration:    FormatISODuration(e.Duration),
+		Description: e.Description,
+		UserID:      e.UserID,
+		Reminders:   e.Reminders,
+		Category:    e.Category,
+		Color:       e.Color,
+		Tags:        e.Tags,
+		AllDay:      e.AllDay,
+	}
+	if e.AllDay {
+		first, last := e.Dates()
+		out.StartDate, out.EndDate = first.Format(DateLayout), last.Format(DateLayout)
+	}
+	if !e.TimeToNotify.IsZero() {
+		out.TimeToNotify = &e.TimeToNotify
+	}
+	if out.Reminders == nil {
+		out.Reminders = []Reminder{}
+	}
+	if out.Tags == nil {
+		out.Tags = []string{}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON пропускает поля, которые вычисляет сервис: timeToNotify и состояние напоминаний.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var in eventJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	var duration time.Duration
+	if in.Duration != "" {
+		var err error
+		if duration, err = ParseISODuration(in.Duration); err != nil {
+			return err
+		}
+	}
+
+	*e = Event{
+		ID:          in.ID,
+		Title:       in.Title,
+		EventTime:   in.EventTime,
+		Duration:    duration,
+		Description: in.Description,
+		UserID:      in.UserID,
+		Reminders:   in.Reminders,
+		Category:    in.Category,
+		Color:       in.Color,
+		Tags:        in.Tags,
+		AllDay:      in.AllDay,
+	}
+	if in.AllDay && in.StartDate != "" {
+		return e.SetDateStrings(in.StartDate, in.EndDate)
+	}
+	return nil
+}
+
+type reminderJSON struct {
+	ID      int            `json:"id,omitempty"`
+	EventID int            `json:"eventId,omitempty"`
+	Offset  string         `json:"offset"`
+	FireAt  *time.Time     `json:"fireAt,omitempty"`
+	Status  ReminderStatus `json:"status,omitempty"`
+	SentAt  *time.Time     `json:"sentAt,omitempty"`
+}
+
+func (r Reminder) MarshalJSON() ([]byte, error) {
+	out := reminderJSON{
+		ID:      r.ID,
+		EventID: r.EventID,
+		Offset:  FormatISODuration(r.Offset),
+		Status:  r.Status,
+	}
+	if !r.FireAt.IsZero() {
+		out.FireAt = &r.FireAt
+	}
+	if !r.SentAt.IsZero() {
+		out.SentAt = &r.SentAt
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON читает только смещение: остальные поля напоминания вычисляет сервис.
+func (r *Reminder) UnmarshalJSON(data []byte) error {
+	var in reminderJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	offset, err := ParseISODuration(in.Offset)
+	if err != nil {
+		return err
+	}
+	*r = Reminder{Offset: offset}
+	return nil
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestISODuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT0S":       0,
+		"PT1H30M":    90 * time.Minute,
+		"P1DT2H":     26 * time.Hour,
+		"P2D":        48 * time.Hour,
+		"PT10M":      10 * time.Minute,
+		"PT1.5S":     1500 * time.Millisecond,
+		"-PT15M":     -15 * time.Minute,
+		"P1DT1H1M1S": day + time.Hour + time.Minute + time.Second,
+	}
+	for iso, d := range cases {
+		assert.Equal(t, iso, FormatISODuration(d))
+
+		parsed, err := ParseISODuration(iso)
+		require.NoError(t, err, iso)
+		assert.Equal(t, d, parsed, iso)
+	}
+
+	parsed, err := ParseISODuration("P1W")
+	require.NoError(t, err)
+	assert.Equal(t, 7*day, parsed)
+
+	for _, bad := range []string{"", "P", "PT", "1H", "P1Y", "P1M", "PT1M1H", "PTH", "P-1D", "3600"} {
+		_, err := ParseISODuration(bad)
+		assert.ErrorIs(t, err, ErrInvalidISODuration, bad)
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	event := Event{
+		ID:          1,
+		Title:       "Standup",
+		EventTime:   time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC),
+		Duration:    15 * time.Minute,
+		Description: "daily",
+		UserID:      3,
+		Reminders:   []Reminder{{ID: 2, EventID: 1, Offset: 10 * time.Minute, Status: ReminderPending}},
+		Category:    "work",
+		Tags:        []string{"team"},
+	}
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": 1,
+		"title": "Standup",
+		"eventTime": "2025-11-05T10:00:00Z",
+		"duration": "PT15M",
+		"description": "daily",
+		"userId": 3,
+		"reminders": [{"id": 2, "eventId": 1, "offset": "PT10M", "status": "pending"}],
+		"category": "work",
+		"color": "",
+		"tags": ["team"],
+		"allDay": false
+	}`, string(data))
+
+	var decoded Event
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, event.EventTime, decoded.EventTime)
+	assert.Equal(t, event.Duration, decoded.Duration)
+	assert.Equal(t, 3, decoded.UserID)
+	assert.Equal(t, []Reminder{{Offset: 10 * time.Minute}}, decoded.Reminders)
+	assert.Equal(t, []string{"team"}, decoded.Tags)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"duration": "1h"}`), &decoded), ErrInvalidISODuration)
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+type Notification struct {
+	EventID     int       `json:"eventId"`
+	Title       string    `json:"title"`
+	EventTime   time.Time `json:"eventTime"`
+	Description string    `json:"description"`
+	UserID      int       `json:"userId"`
+}
+
+func NewNotification(e Event) Notification {
+	return Notification{
+		EventID:     e.ID,
+		Title:       e.Title,
+		EventTime:   e.EventTime,
+		Description: e.Description,
+		UserID:      e.UserID,
+	}
+}
+
+type ChannelType string
+
+const (
+	ChannelLog   ChannelType = "log"
+	ChannelEmail ChannelType = "email"
+	ChannelHTTP  ChannelType = "http"
+)
+
+type NotificationChannel struct {
+	UserID  int         `json:"userId"`
+	Type    ChannelType `json:"type"`
+	Address string      `json:"address"`
+}
+
+func (c *NotificationChannel) Validate() error {
+	switch c.Type {
+	case ChannelLog:
+		return nil
+	case ChannelEmail:
+		if _, err := mail.ParseAddress(c.Address); err != nil {
+			return ErrInvalidChannelAddress
+		}
+		return nil
+	case ChannelHTTP:
+		u, err := url.Parse(c.Address)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return ErrInvalidChannelAddress
+		}
+		return nil
+	default:
+		return ErrUnknownChannelType
+	}
+}
+
+var (
+	ErrUnknownChannelType    = errors.New("unknown notification channel type")
+	ErrInvalidChannelAddress = errors.New("invalid notification channel address")
+	ErrChannelNotFound       = errors.New("notification channel not found")
+)
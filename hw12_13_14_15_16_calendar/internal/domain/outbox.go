@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// TopicNotifications — топик уведомлений о наступивших напоминаниях, Payload — Notification в JSON.
+const TopicNotifications = "notifications"
+
+// OutboxMessage сохраняется в одной транзакции с изменением, которое его породило,
+// и публикуется в очередь отдельно. Публикация повторяется до подтверждения,
+// поэтому получатель отбрасывает повторы по ID, сверяясь с ProcessedAt.
+type OutboxMessage struct {
+	ID           int
+	Topic        string
+	Payload      []byte
+	CreatedAt    time.Time
+	DispatchedAt time.Time
+	// ClaimedBy — relay, который публикует сообщение; до ClaimedUntil другие его не берут.
+	ClaimedBy    string
+	ClaimedUntil time.Time
+	ProcessedAt  time.Time
+}
+
+func (m *OutboxMessage) Dispatched() bool {
+	return !m.DispatchedAt.IsZero()
+}
+
+// Claimable сообщает, может ли owner взять сообщение в момент now.
+func (m *OutboxMessage) Claimable(owner string, now time.Time) bool {
+	return !m.Dispatched() && (m.ClaimedBy == owner || !m.ClaimedUntil.After(now))
+}
+
+var ErrReminderAlreadySent = errors.New("reminder already sent")
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type ReminderStatus string
+
+const (
+	ReminderPending ReminderStatus = "pending"
+	ReminderSent    ReminderStatus = "sent"
+)
+
+type Reminder struct {
+	ID      int
+	EventID int
+	// Offset — за сколько до начала события отправить напоминание.
+	Offset time.Duration
+	FireAt time.Time
+	Status ReminderStatus
+	SentAt time.Time
+}
+
+func (r *Reminder) Validate() error {
+	if r.Offset < 0 {
+		return ErrInvalidReminderOffset
+	}
+	return nil
+}
+
+// ScheduleReminders пересчитывает время срабатывания напоминаний от EventTime.
+// Напоминание с тем же смещением сохраняет ID, а отправленным остаётся,
+// только если время срабатывания не изменилось.
+func (e *Event) ScheduleReminders(previous []Reminder) {
+	byOffset := make(map[time.Duration]Reminder, len(previous))
+	for _, r := range previous {
+		byOffset[r.Offset] = r
+	}
+
+	seen := make(map[time.Duration]bool, len(e.Reminders))
+	reminders := make([]Reminder, 0, len(e.Reminders))
+
+	for _, r := range e.Reminders {
+		if seen[r.Offset] {
+			continue
+		}
+		seen[r.Offset] = true
+
+		scheduled := Reminder{
+			EventID: e.ID,
+			Offset:  r.Offset,
+			FireAt:  e.EventTime.Add(-r.Offset),
+			Status:  ReminderPending,
+		}
+
+		if prev, ok := byOffset[r.Offset]; ok {
+			scheduled.ID = prev.ID
+			if prev.Status == ReminderSent && prev.FireAt.Equal(scheduled.FireAt) {
+				scheduled.Status = ReminderSent
+				scheduled.SentAt = prev.SentAt
+			}
+		}
+
+		reminders = append(reminders, scheduled)
+	}
+
+	e.Reminders = reminders
+}
+
+// RestoreReminders пересчитывает напоминания события из резервной копии так же,
+// как ScheduleReminders, но сохраняет их статусы. ID напоминаний назначаются заново.
+func (e *Event) RestoreReminders() {
+	previous := make([]Reminder, len(e.Reminders))
+	for i, r := range e.Reminders {
+		r.ID = 0
+		r.FireAt = e.EventTime.Add(-r.Offset)
+		previous[i] = r
+	}
+	e.ScheduleReminders(previous)
+}
+
+var (
+	ErrInvalidReminderOffset = errors.New("reminder offset must not be negative")
+	ErrReminderNotFound      = errors.New("reminder not found")
+)
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// Границы подсветки совпадений во фрагменте результата.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+	// SnippetMaxWords — наибольшая длина фрагмента в словах.
+	SnippetMaxWords = 30
+)
+
+// SearchQuery ищет события, в названии или описании которых есть все слова Text
+// (слово запроса совпадает с началом слова события). Нулевые фильтры не применяются.
+type SearchQuery struct {
+	Text   string
+	UserID int
+	From   time.Time
+	To     time.Time
+	Tags   TagFilter
+	Limit  int
+}
+
+func (q *SearchQuery) Validate() error {
+	if len(Tokenize(q.Text)) == 0 {
+		return ErrEmptySearchQuery
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.To.After(q.From) {
+		return ErrInvalidSearchRange
+	}
+	if q.Limit < 0 || q.Limit > MaxSearchLimit {
+		return ErrInvalidSearchLimit
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	return nil
+}
+
+type SearchResult struct {
+	Event Event
+	// Rank — релевантность, больше — лучше; сравнима только внутри одного хранилища.
+	Rank float64
+	// Snippet — фрагмент описания (или названия) с совпадениями между HighlightStart и HighlightStop.
+	Snippet string
+}
+
+// Tokenize разбивает текст на слова в нижнем регистре без повторов.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	tokens := words[:0]
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+var (
+	ErrEmptySearchQuery   = errors.New("search query must contain at least one word")
+	ErrInvalidSearchRange = errors.New("search range end must be after its start")
+	ErrInvalidSearchLimit = errors.New("search limit must be between 1 and 100")
+)
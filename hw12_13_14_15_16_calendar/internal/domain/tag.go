@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags           = 20
+	MaxTagLength      = 32
+	MaxCategoryLength = 64
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// NormalizeTags приводит теги к нижнему регистру, убирает пустые и повторы и сортирует.
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func validateTag(tag string) error {
+	if utf8.RuneCountInString(tag) > MaxTagLength || strings.IndexFunc(tag, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	}) >= 0 {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// normalizeLabels приводит теги, категорию и цвет события к каноническому виду и проверяет их.
+func (e *Event) normalizeLabels() error {
+	e.Tags = NormalizeTags(e.Tags)
+	if len(e.Tags) > MaxTags {
+		return ErrTooManyTags
+	}
+	for _, tag := range e.Tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	e.Category = strings.TrimSpace(e.Category)
+	if utf8.RuneCountInString(e.Category) > MaxCategoryLength {
+		return ErrInvalidCategory
+	}
+
+	e.Color = strings.ToLower(e.Color)
+	if e.Color != "" && !colorPattern.MatchString(e.Color) {
+		return ErrInvalidColor
+	}
+	return nil
+}
+
+type TagMatch string
+
+const (
+	// TagMatchAll отбирает события со всеми тегами фильтра.
+	TagMatchAll TagMatch = "all"
+	// TagMatchAny отбирает события хотя бы с одним тегом фильтра.
+	TagMatchAny TagMatch = "any"
+)
+
+// TagFilter пустой, если в нём нет тегов, и тогда пропускает все события.
+type TagFilter struct {
+	Tags  []string
+	Match TagMatch
+}
+
+// NewTagFilter принимает теги списком и через запятую; match по умолчанию TagMatchAll.
+func NewTagFilter(tags []string, match string) (TagFilter, error) {
+	filter := TagFilter{Match: TagMatch(match)}
+	switch filter.Match {
+	case "":
+		filter.Match = TagMatchAll
+	case TagMatchAll, TagMatchAny:
+	default:
+		return TagFilter{}, ErrInvalidTagMatch
+	}
+
+	var split []string
+	for _, tag := range tags {
+		split = append(split, strings.Split(tag, ",")...)
+	}
+	filter.Tags = NormalizeTags(split)
+
+	for _, tag := range filter.Tags {
+		if err := validateTag(tag); err != nil {
+			return TagFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+func (f TagFilter) Empty() bool {
+	return len(f.Tags) == 0
+}
+
+func (f TagFilter) Matches(tags []string) bool {
+	if f.Empty() {
+		return true
+	}
+
+	has := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		has[tag] = true
+	}
+
+	for _, tag := range f.Tags {
+		if has[tag] && f.Match == TagMatchAny {
+			return true
+		}
+		if !has[tag] && f.Match != TagMatchAny {
+			return false
+		}
+	}
+	return f.Match != TagMatchAny
+}
+
+// TagCount — сколько событий пользователя отмечено тегом.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+var (
+	ErrInvalidTag      = errors.New("tag must be at most 32 characters without spaces and commas")
+	ErrTooManyTags     = errors.New("event must not have more than 20 tags")
+	ErrInvalidCategory = errors.New("category must be at most 64 characters")
+	ErrInvalidColor    = errors.New("color must be in #rrggbb format")
+	ErrInvalidTagMatch = errors.New("tag match must be all or any")
+)
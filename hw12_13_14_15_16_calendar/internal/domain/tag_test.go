@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent_ValidateNormalizesLabels(t *testing.T) {
+	event := Event{
+		Title:     "Standup",
+		EventTime: time.Now(),
+		Duration:  time.Hour,
+		Category:  " Work ",
+		Color:     "#FFAA00",
+		Tags:      []string{"On-Call", " 1:1", "on-call", ""},
+	}
+	require.NoError(t, event.Validate())
+	assert.Equal(t, "Work", event.Category)
+	assert.Equal(t, "#ffaa00", event.Color)
+	assert.Equal(t, []string{"1:1", "on-call"}, event.Tags)
+
+	event.Tags = []string{"two words"}
+	assert.ErrorIs(t, event.Validate(), ErrInvalidTag)
+
+	event.Tags = nil
+	event.Color = "red"
+	assert.ErrorIs(t, event.Validate(), ErrInvalidColor)
+}
+
+func TestTagFilter(t *testing.T) {
+	all, err := NewTagFilter([]string{"on-call,1:1"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1:1", "on-call"}, all.Tags)
+	assert.True(t, all.Matches([]string{"1:1", "on-call", "team"}))
+	assert.False(t, all.Matches([]string{"on-call"}))
+
+	anyOf, err := NewTagFilter([]string{"On-Call", "1:1"}, "any")
+	require.NoError(t, err)
+	assert.True(t, anyOf.Matches([]string{"on-call"}))
+	assert.False(t, anyOf.Matches([]string{"team"}))
+
+	empty, err := NewTagFilter(nil, "any")
+	require.NoError(t, err)
+	assert.True(t, empty.Matches(nil))
+
+	_, err = NewTagFilter([]string{"x"}, "some")
+	assert.ErrorIs(t, err, ErrInvalidTagMatch)
+}
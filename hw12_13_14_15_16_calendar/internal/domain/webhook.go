@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+type EventAction string
+
+const (
+	EventCreated  EventAction = "event.created"
+	EventUpdated  EventAction = "event.updated"
+	EventDeleted  EventAction = "event.deleted"
+	EventNotified EventAction = "event.notified"
+)
+
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+type WebhookDelivery struct {
+	ID         int         `json:"id"`
+	WebhookID  int         `json:"webhookId"`
+	EventID    int         `json:"eventId"`
+	Action     EventAction `json:"action"`
+	Attempt    int         `json:"attempt"`
+	StatusCode int         `json:"statusCode"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+var (
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http(s) url")
+	ErrWebhookNotFound   = errors.New("webhook not found")
+)
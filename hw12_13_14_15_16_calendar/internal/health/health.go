@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker собирает проверки зависимостей для readiness.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит readiness в fail, чтобы балансировщик перестал слать трафик.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Live() Report {
+	return Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)+1)}
+	report.add("shutdown", func() error {
+		if c.shuttingDown.Load() {
+			return errShuttingDown
+		}
+		return nil
+	}())
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := nc.check(ctx)
+
+			mu.Lock()
+			report.add(nc.name, err)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (r *Report) add(name string, err error) {
+	if err != nil {
+		r.Status = StatusFail
+		r.Checks[name] = CheckResult{Status: StatusFail, Error: err.Error()}
+		return
+	}
+	r.Checks[name] = CheckResult{Status: StatusOK}
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	checker := NewChecker(0)
+	checker.Add("storage", func(context.Context) error { return nil })
+
+	report := checker.Ready(context.Background())
+	assert.True(t, report.OK())
+	assert.Equal(t, StatusOK, report.Checks["storage"].Status)
+	assert.Equal(t, StatusOK, report.Checks["shutdown"].Status)
+}
+
+func TestChecker_FailedDependency(t *testing.T) {
+	checker := NewChecker(0)
+	checker.Add("storage", func(context.Context) error { return nil })
+	checker.Add("migrations", func(context.Context) error { return errors.New("version 1 is behind 3") })
+
+	report := checker.Ready(context.Background())
+	assert.False(t, report.OK())
+	assert.Equal(t, StatusOK, report.Checks["storage"].Status)
+	require.Equal(t, StatusFail, report.Checks["migrations"].Status)
+	assert.Equal(t, "version 1 is behind 3", report.Checks["migrations"].Error)
+}
+
+func TestChecker_ShuttingDown(t *testing.T) {
+	checker := NewChecker(0)
+	require.True(t, checker.Ready(context.Background()).OK())
+
+	checker.SetShuttingDown()
+
+	report := checker.Ready(context.Background())
+	assert.False(t, report.OK())
+	assert.Equal(t, StatusFail, report.Checks["shutdown"].Status)
+	assert.True(t, checker.Live().OK())
+}
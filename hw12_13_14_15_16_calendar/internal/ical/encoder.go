@@ -0,0 +1,126 @@
+// Package ical выгружает события в формате iCalendar (RFC 5545).
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// maxLineOctets — предел длины строки, дальше строка переносится.
+	maxLineOctets = 75
+)
+
+// Encoder пишет события по одному, чтобы выгрузку можно было вести потоком.
+// Заголовок календаря пишется перед первым событием, окончание — в Close.
+type Encoder struct {
+	w       *bufio.Writer
+	stamp   time.Time
+	started bool
+}
+
+// NewEncoder создаёт кодировщик; stamp попадает в DTSTAMP всех событий.
+func NewEncoder(w io.Writer, stamp time.Time) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), stamp: stamp.UTC()}
+}
+
+func (e *Encoder) Encode(event domain.Event) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+
+	e.line("BEGIN:VEVENT")
+	e.line(fmt.Sprintf("UID:%d@calendar", event.ID))
+	e.line("DTSTAMP:" + e.stamp.Format(dateTimeLayout))
+	if event.AllDay {
+		// Для дат DTEND не входит в событие, поэтому это день после последнего.
+		e.line("DTSTART;VALUE=DATE:" + event.EventTime.Format(dateLayout))
+		e.line("DTEND;VALUE=DATE:" + event.GetEndTime().Format(dateLayout))
+	} else {
+		e.line("DTSTART:" + event.EventTime.UTC().Format(dateTimeLayout))
+		e.line("DTEND:" + event.GetEndTime().UTC().Format(dateTimeLayout))
+	}
+	e.line("SUMMARY:" + escape(event.Title))
+	if event.Description != "" {
+		e.line("DESCRIPTION:" + escape(event.Description))
+	}
+	if event.Category != "" || len(event.Tags) > 0 {
+		var categories []string
+		if event.Category != "" {
+			categories = append(categories, escape(event.Category))
+		}
+		for _, tag := range event.Tags {
+			categories = append(categories, escape(tag))
+		}
+		e.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	if event.Color != "" {
+		e.line("COLOR:" + event.Color)
+	}
+	for _, r := range event.Reminders {
+		e.line("BEGIN:VALARM")
+		e.line("ACTION:DISPLAY")
+		e.line("DESCRIPTION:" + escape(event.Title))
+		e.line("TRIGGER:-" + domain.FormatISODuration(r.Offset))
+		e.line("END:VALARM")
+	}
+	e.line("END:VEVENT")
+
+	return e.w.Flush()
+}
+
+// Close завершает календарь; пустой календарь тоже получается корректным.
+func (e *Encoder) Close() error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.line("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+func (e *Encoder) begin() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:-//otus-go//calendar//RU")
+	e.line("CALSCALE:GREGORIAN")
+	return e.w.Flush()
+}
+
+// line пишет строку содержимого с переносом по maxLineOctets байт, не разрывая символы UTF-8.
+// Ошибки записи копит bufio.Writer и возвращает при Flush.
+func (e *Encoder) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		e.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Продолжение начинается с пробела, который тоже занимает байт.
+		limit = maxLineOctets - 1
+	}
+	e.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,87 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	vacation := domain.Event{ID: 2, Title: "Vacation; Sochi, sea"}
+	require.NoError(t, vacation.SetDates(
+		time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 12, 0, 0, 0, 0, time.UTC)))
+
+	var b strings.Builder
+	enc := NewEncoder(&b, time.Date(2025, 11, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, enc.Encode(domain.Event{
+		ID:          1,
+		Title:       "Standup",
+		EventTime:   time.Date(2025, 11, 5, 13, 0, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Duration:    15 * time.Minute,
+		Description: "line one\nline two",
+		Category:    "work",
+		Tags:        []string{"team"},
+		Reminders:   []domain.Reminder{{Offset: 10 * time.Minute}},
+	}))
+	require.NoError(t, enc.Encode(vacation))
+	require.NoError(t, enc.Close())
+
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//otus-go//calendar//RU",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:1@calendar",
+		"DTSTAMP:20251101T090000Z",
+		"DTSTART:20251105T100000Z",
+		"DTEND:20251105T101500Z",
+		"SUMMARY:Standup",
+		`DESCRIPTION:line one\nline two`,
+		"CATEGORIES:work,team",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"DESCRIPTION:Standup",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2@calendar",
+		"DTSTAMP:20251101T090000Z",
+		"DTSTART;VALUE=DATE:20251110",
+		"DTEND;VALUE=DATE:20251113",
+		`SUMMARY:Vacation\; Sochi\, sea`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), b.String())
+}
+
+func TestEncoder_FoldsLongLines(t *testing.T) {
+	var b strings.Builder
+	enc := NewEncoder(&b, time.Now())
+	require.NoError(t, enc.Encode(domain.Event{
+		ID: 1, Title: strings.Repeat("Планёрка ", 20), EventTime: time.Now(), Duration: time.Hour,
+	}))
+	require.NoError(t, enc.Close())
+
+	var summary strings.Builder
+	inSummary := false
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		switch {
+		case strings.HasPrefix(line, "SUMMARY:"):
+			inSummary = true
+			summary.WriteString(line)
+		case inSummary && strings.HasPrefix(line, " "):
+			summary.WriteString(line[1:])
+		default:
+			inSummary = false
+		}
+	}
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("Планёрка ", 20), summary.String())
+}
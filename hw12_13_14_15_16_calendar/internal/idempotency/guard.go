@@ -0,0 +1,137 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+type Storage interface {
+	Idempotency() storage.IdempotencyRepository
+}
+
+// Guard выполняет запросы с ключом идемпотентности не больше одного раза.
+type Guard struct {
+	logger  Logger
+	storage Storage
+	conf    config.IdempotencyConf
+	now     func() time.Time
+}
+
+func New(logger Logger, storage Storage, conf config.IdempotencyConf) *Guard {
+	if conf.TTL <= 0 {
+		conf.TTL = 24 * time.Hour
+	}
+	if conf.CleanupInterval <= 0 {
+		conf.CleanupInterval = 10 * time.Minute
+	}
+	if conf.RequestTimeout <= 0 {
+		conf.RequestTimeout = 30 * time.Second
+	}
+	if conf.LockTimeout <= conf.RequestTimeout {
+		conf.LockTimeout = 4 * conf.RequestTimeout
+	}
+	return &Guard{logger: logger, storage: storage, conf: conf, now: time.Now}
+}
+
+// Do вызывает fn и запоминает её ответ под ключом key пользователя userID на время TTL.
+// Повтор с тем же request возвращает сохранённый ответ и replayed = true, с другим —
+// ErrIdempotencyKeyMismatch, а пока первый запрос не завершён — ErrIdempotencyInProgress.
+// Ошибка fn не запоминается: ключ освобождается, и запрос можно повторить.
+// fn получает контекст с RequestTimeout, который истекает раньше блокировки ключа.
+func (g *Guard) Do(
+	ctx context.Context, userID int, key string, request []byte, fn func(ctx context.Context) ([]byte, error),
+) (response []byte, replayed bool, err error) {
+	if err := domain.ValidateIdempotencyKey(key); err != nil {
+		return nil, false, err
+	}
+
+	sum := sha256.Sum256(request)
+	now := g.now().UTC()
+	rec := domain.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: hex.EncodeToString(sum[:]),
+		Owner:       newOwner(),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(g.conf.LockTimeout),
+	}
+
+	stored, created, err := g.storage.Idempotency().Begin(ctx, rec)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		switch {
+		case stored.RequestHash != rec.RequestHash:
+			return nil, false, domain.ErrIdempotencyKeyMismatch
+		case !stored.Completed():
+			return nil, false, domain.ErrIdempotencyInProgress
+		}
+		return stored.Response, true, nil
+	}
+
+	// Ключ освобождается и сохраняется даже при отмене запроса: сам запрос уже выполнен.
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	fnCtx, cancel := context.WithTimeout(ctx, g.conf.RequestTimeout)
+	defer cancel()
+
+	response, err = fn(fnCtx)
+	if err != nil {
+		if err := g.storage.Idempotency().Delete(cleanupCtx, userID, key, rec.Owner); err != nil {
+			g.logger.Error(fmt.Sprintf("failed to release idempotency key %q: %v", key, err))
+		}
+		return nil, false, err
+	}
+
+	err = g.storage.Idempotency().Complete(cleanupCtx, userID, key, rec.Owner, response, now.Add(g.conf.TTL))
+	if err != nil {
+		g.logger.Error(fmt.Sprintf("failed to save response for idempotency key %q: %v", key, err))
+	}
+	return response, false, nil
+}
+
+// Run периодически удаляет просроченные ключи до отмены ctx.
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.conf.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Cleanup(ctx)
+		}
+	}
+}
+
+func (g *Guard) Cleanup(ctx context.Context) {
+	n, err := g.storage.Idempotency().DeleteExpired(ctx, g.now().UTC())
+	if err != nil {
+		g.logger.Error(fmt.Sprintf("failed to delete expired idempotency keys: %v", err))
+		return
+	}
+	if n > 0 {
+		g.logger.Info(fmt.Sprintf("deleted %d expired idempotency keys", n))
+	}
+}
+
+func newOwner() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
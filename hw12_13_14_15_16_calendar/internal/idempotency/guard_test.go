@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFailed = errors.New("failed")
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+
+func TestGuard_Do(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 11, 25, 12, 0, 0, 0, time.UTC)
+	guard := New(nopLogger{}, memorystorage.NewStorage(), config.IdempotencyConf{TTL: time.Hour})
+	guard.now = func() time.Time { return now }
+
+	calls := 0
+	fn := func(context.Context) ([]byte, error) {
+		calls++
+		return []byte("created"), nil
+	}
+
+	_, _, err := guard.Do(ctx, 1, "", []byte("req"), fn)
+	require.ErrorIs(t, err, domain.ErrInvalidIdempotencyKey)
+
+	resp, replayed, err := guard.Do(ctx, 1, "key", []byte("req"), fn)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, "created", string(resp))
+
+	resp, replayed, err = guard.Do(ctx, 1, "key", []byte("req"), fn)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, "created", string(resp))
+	assert.Equal(t, 1, calls)
+
+	_, _, err = guard.Do(ctx, 1, "key", []byte("other"), fn)
+	require.ErrorIs(t, err, domain.ErrIdempotencyKeyMismatch)
+
+	t.Run("in progress", func(t *testing.T) {
+		_, _, err := guard.Do(ctx, 1, "slow", []byte("req"), func(context.Context) ([]byte, error) {
+			_, _, err := guard.Do(ctx, 1, "slow", []byte("req"), fn)
+			return nil, err
+		})
+		require.ErrorIs(t, err, domain.ErrIdempotencyInProgress)
+
+		// Ошибка освобождает ключ.
+		_, replayed, err := guard.Do(ctx, 1, "slow", []byte("req"), fn)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+
+		_, replayed, err := guard.Do(ctx, 1, "key", []byte("other"), fn)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+
+		now = now.Add(2 * time.Hour)
+		guard.Cleanup(ctx)
+		n, err := guard.storage.Idempotency().DeleteExpired(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("abandoned request", func(t *testing.T) {
+		// Процесс упал, не дописав ответ: ключ занят только до истечения LockTimeout.
+		_, _, err := guard.storage.Idempotency().Begin(ctx, domain.IdempotencyRecord{
+			UserID: 1, Key: "crash", RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(guard.conf.LockTimeout),
+		})
+		require.NoError(t, err)
+
+		_, _, err = guard.Do(ctx, 1, "crash", []byte("req"), fn)
+		require.ErrorIs(t, err, domain.ErrIdempotencyKeyMismatch)
+
+		now = now.Add(guard.conf.LockTimeout)
+		_, replayed, err := guard.Do(ctx, 1, "crash", []byte("req"), fn)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+	})
+}
+
+func TestGuard_LockOutlivesRequest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 11, 25, 12, 0, 0, 0, time.UTC)
+	guard := New(nopLogger{}, memorystorage.NewStorage(), config.IdempotencyConf{
+		TTL: time.Hour, LockTimeout: 2 * time.Minute, RequestTimeout: 30 * time.Second,
+	})
+	guard.now = func() time.Time { return now }
+
+	_, _, err := guard.Do(ctx, 1, "key", []byte("req"), func(ctx context.Context) ([]byte, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), deadline, time.Second)
+		return []byte("ok"), nil
+	})
+	require.NoError(t, err)
+}
+
+func TestGuard_KeepsKeyTakenOver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 11, 25, 12, 0, 0, 0, time.UTC)
+	guard := New(nopLogger{}, memorystorage.NewStorage(), config.IdempotencyConf{
+		TTL: time.Hour, LockTimeout: 2 * time.Minute, RequestTimeout: 30 * time.Second,
+	})
+	guard.now = func() time.Time { return now }
+
+	retry := func(context.Context) ([]byte, error) { return []byte("retry"), nil }
+
+	// Первый запрос завис дольше блокировки, и ключ занял повтор. Ни ошибка, ни ответ
+	// первого запроса не должны затереть результат повтора.
+	for key, result := range map[string]error{"failed": errFailed, "completed": nil} {
+		_, _, err := guard.Do(ctx, 1, key, []byte("req"), func(context.Context) ([]byte, error) {
+			now = now.Add(guard.conf.LockTimeout)
+			resp, replayed, err := guard.Do(ctx, 1, key, []byte("req"), retry)
+			require.NoError(t, err)
+			require.False(t, replayed)
+			require.Equal(t, "retry", string(resp))
+			return []byte("first"), result
+		})
+		require.ErrorIs(t, err, result)
+
+		resp, replayed, err := guard.Do(ctx, 1, key, []byte("req"), retry)
+		require.NoError(t, err)
+		assert.True(t, replayed, key)
+		assert.Equal(t, "retry", string(resp), key)
+	}
+}
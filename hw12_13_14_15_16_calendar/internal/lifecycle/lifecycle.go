@@ -0,0 +1,155 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+// Component описывает часть сервиса, которой управляет Manager. Любая из функций может быть nil.
+type Component struct {
+	Name string
+	// Start синхронно запускает компонент: после возврата им могут пользоваться следующие компоненты.
+	Start func(ctx context.Context) error
+	// Run выполняет фоновую работу до отмены ctx. Завершение Run до остановки сервиса,
+	// в том числе без ошибки, считается сбоем и запускает общую остановку.
+	Run func(ctx context.Context) error
+	// Stop останавливает компонент, укладываясь в ctx.
+	Stop func(ctx context.Context) error
+}
+
+// Manager запускает компоненты в порядке добавления и останавливает в обратном.
+type Manager struct {
+	logger      Logger
+	stopTimeout time.Duration
+	components  []Component
+
+	failOnce sync.Once
+	failed   chan error
+}
+
+func New(logger Logger, stopTimeout time.Duration) *Manager {
+	return &Manager{
+		logger:      logger,
+		stopTimeout: stopTimeout,
+		failed:      make(chan error, 1),
+	}
+}
+
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+type running struct {
+	component Component
+	cancel    context.CancelFunc
+	done      chan struct{}
+	stopping  atomic.Bool
+}
+
+// Run запускает все компоненты и ждёт отмены ctx или сбоя одного из них, после чего
+// останавливает запущенные компоненты в обратном порядке в пределах stopTimeout.
+// Возвращает nil только при штатной остановке по ctx.
+func (m *Manager) Run(ctx context.Context) error {
+	var started []*running
+
+	startErr := func() error {
+		for _, c := range m.components {
+			if c.Start != nil {
+				if err := c.Start(ctx); err != nil {
+					return fmt.Errorf("failed to start %s: %w", c.Name, err)
+				}
+			}
+
+			r := &running{component: c, done: make(chan struct{})}
+			started = append(started, r)
+
+			if c.Run == nil {
+				close(r.done)
+				continue
+			}
+
+			runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			r.cancel = cancel
+			go func() {
+				defer close(r.done)
+				err := c.Run(runCtx)
+				if r.stopping.Load() {
+					return
+				}
+				if err == nil {
+					err = errors.New("stopped unexpectedly")
+				}
+				m.fail(fmt.Errorf("%s failed: %w", c.Name, err))
+			}()
+		}
+		return nil
+	}()
+
+	var runErr error
+	if startErr == nil {
+		m.logger.Info("all components started")
+		select {
+		case <-ctx.Done():
+		case runErr = <-m.failed:
+		}
+	}
+
+	if startErr != nil {
+		m.logger.Error(startErr.Error())
+	}
+	if runErr != nil {
+		m.logger.Error(runErr.Error())
+	}
+
+	stopErr := m.stop(started)
+
+	return errors.Join(startErr, runErr, stopErr)
+}
+
+func (m *Manager) fail(err error) {
+	m.failOnce.Do(func() {
+		m.failed <- err
+	})
+}
+
+func (m *Manager) stop(started []*running) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		r.stopping.Store(true)
+
+		if r.component.Stop != nil {
+			if err := r.component.Stop(ctx); err != nil {
+				err = fmt.Errorf("failed to stop %s: %w", r.component.Name, err)
+				m.logger.Error(err.Error())
+				errs = append(errs, err)
+			}
+		}
+
+		if r.cancel != nil {
+			r.cancel()
+		}
+
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			err := fmt.Errorf("failed to stop %s: %w", r.component.Name, ctx.Err())
+			m.logger.Error(err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
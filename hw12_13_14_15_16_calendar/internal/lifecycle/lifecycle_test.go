@@ -0,0 +1,112 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.add("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestManager_StartsInOrderAndStopsInReverse(t *testing.T) {
+	rec := &recorder{}
+	manager := New(nopLogger{}, time.Second)
+	manager.Add(rec.component("storage"))
+	manager.Add(rec.component("scheduler"))
+	manager.Add(rec.component("server"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, manager.Run(ctx))
+	assert.Equal(t, []string{
+		"start storage", "start scheduler", "start server",
+		"stop server", "stop scheduler", "stop storage",
+	}, rec.events)
+}
+
+func TestManager_StartFailureStopsStartedComponents(t *testing.T) {
+	rec := &recorder{}
+	manager := New(nopLogger{}, time.Second)
+	manager.Add(rec.component("storage"))
+	manager.Add(Component{
+		Name:  "server",
+		Start: func(context.Context) error { return errors.New("address already in use") },
+		Stop: func(context.Context) error {
+			rec.add("stop server")
+			return nil
+		},
+	})
+
+	err := manager.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start server")
+	assert.Equal(t, []string{"start storage", "stop storage"}, rec.events)
+}
+
+func TestManager_RunFailureTriggersShutdown(t *testing.T) {
+	rec := &recorder{}
+	manager := New(nopLogger{}, time.Second)
+	manager.Add(rec.component("storage"))
+	manager.Add(Component{
+		Name: "server",
+		Run:  func(context.Context) error { return errors.New("listener closed") },
+	})
+
+	err := manager.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server failed: listener closed")
+	assert.Equal(t, []string{"start storage", "stop storage"}, rec.events)
+}
+
+func TestManager_StopDeadline(t *testing.T) {
+	manager := New(nopLogger{}, 50*time.Millisecond)
+	manager.Add(Component{
+		Name: "stuck",
+		Run: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := manager.Run(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FieldRequestID = "request_id"
+	FieldUserID    = "user_id"
+	FieldEventID   = "event_id"
+)
+
+type Fields = logrus.Fields
+
+type fieldsKey struct{}
+
+// WithFields возвращает контекст, записи из которого будут содержать переданные поля.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	merged := make(Fields, len(fields))
+	for key, value := range FieldsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func WithField(ctx context.Context, key string, value interface{}) context.Context {
+	return WithFields(ctx, Fields{key: value})
+}
+
+func FieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// RequestID возвращает идентификатор запроса, сохранённый в ctx middleware'ом.
+func RequestID(ctx context.Context) string {
+	id, _ := FieldsFromContext(ctx)[FieldRequestID].(string)
+	return id
+}
+
+// contextHook дополняет записи, созданные через WithContext, полями контекста и трейса.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for key, value := range FieldsFromContext(entry.Context) {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+	if traceID := tracing.TraceID(entry.Context); traceID != "" {
+		entry.Data["trace_id"] = traceID
+		entry.Data["span_id"] = tracing.SpanID(entry.Context)
+	}
+	return nil
+}
@@ -1,18 +1,32 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
 )
 
 type Logger struct {
 	*logrus.Logger
-	logFile *os.File
+	logFile *lumberjack.Logger
 }
 
 type CleanFormatter struct{}
@@ -22,7 +36,26 @@ func (f *CleanFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	level := strings.ToUpper(entry.Level.String())
 	message := entry.Message
 
-	return []byte(fmt.Sprintf("%s [%s] %s\n", level, timestamp, message)), nil
+	if len(entry.Data) == 0 {
+		return []byte(fmt.Sprintf("%s [%s] %s\n", level, timestamp, message)), nil
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		value := fmt.Sprint(entry.Data[key])
+		if strings.ContainsAny(value, " \"=") {
+			value = strconv.Quote(value)
+		}
+		fields[i] = key + "=" + value
+	}
+
+	return []byte(fmt.Sprintf("%s [%s] %s %s\n", level, timestamp, message, strings.Join(fields, " "))), nil
 }
 
 func New(conf config.LoggerConf) (*Logger, error) {
@@ -36,16 +69,45 @@ func New(conf config.LoggerConf) (*Logger, error) {
 	logger.SetLevel(logLevel)
 
 	// Настраиваем формат
-	logger.SetFormatter(&CleanFormatter{})
+	switch conf.Format {
+	case "", FormatText:
+		logger.SetFormatter(&CleanFormatter{})
+	case FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+	logger.AddHook(contextHook{})
 
-	// Открываем файл
-	file, err := os.OpenFile(conf.FileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
-	if err != nil {
-		return nil, err
+	switch conf.Output {
+	case "", OutputStdout, OutputFile, OutputBoth:
+	default:
+		return nil, fmt.Errorf("unknown log output %q", conf.Output)
+	}
+
+	var file *lumberjack.Logger
+	if conf.Output != OutputStdout {
+		file = &lumberjack.Logger{
+			Filename:   conf.FileName,
+			MaxSize:    conf.MaxSize,
+			MaxAge:     conf.MaxAge,
+			MaxBackups: conf.MaxBackups,
+			Compress:   conf.Compress,
+		}
+		// lumberjack открывает файл лениво, проверяем доступность сразу при старте.
+		if _, err := file.Write(nil); err != nil {
+			return nil, err
+		}
 	}
 
-	// Вывод и в файл, и в консоль
-	logger.SetOutput(io.MultiWriter(os.Stdout, file))
+	switch conf.Output {
+	case OutputStdout:
+		logger.SetOutput(os.Stdout)
+	case OutputFile:
+		logger.SetOutput(file)
+	default:
+		logger.SetOutput(io.MultiWriter(os.Stdout, file))
+	}
 
 	return &Logger{
 		Logger:  logger,
@@ -54,7 +116,18 @@ func New(conf config.LoggerConf) (*Logger, error) {
 }
 
 func (l *Logger) Close() {
-	l.logFile.Close()
+	if l.logFile != nil {
+		l.logFile.Close()
+	}
+}
+
+// Reopen закрывает файл лога, при следующей записи он будет открыт заново.
+// Нужно после внешней ротации (logrotate), которая переименовала файл.
+func (l *Logger) Reopen() error {
+	if l.logFile == nil {
+		return nil
+	}
+	return l.logFile.Close()
 }
 
 func (l *Logger) SetLogLevel(level string) error {
@@ -62,7 +135,26 @@ func (l *Logger) SetLogLevel(level string) error {
 	if err != nil {
 		return err
 	}
+	if logLevel == l.Logger.GetLevel() {
+		return nil
+	}
 	l.Logger.SetLevel(logLevel)
 	l.Infof("change log level on: %s", level)
 	return nil
 }
+
+func (l *Logger) InfoContext(ctx context.Context, args ...interface{}) {
+	l.WithContext(ctx).Info(args...)
+}
+
+func (l *Logger) WarnContext(ctx context.Context, args ...interface{}) {
+	l.WithContext(ctx).Warn(args...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, args ...interface{}) {
+	l.WithContext(ctx).Error(args...)
+}
+
+func (l *Logger) DebugContext(ctx context.Context, args ...interface{}) {
+	l.WithContext(ctx).Debug(args...)
+}
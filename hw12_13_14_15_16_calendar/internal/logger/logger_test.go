@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_ReopenAfterExternalRotation(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "app.log")
+
+	logg, err := New(config.LoggerConf{Level: "info", FileName: fileName, Output: OutputFile})
+	require.NoError(t, err)
+	defer logg.Close()
+
+	logg.Info("before rotation")
+	require.NoError(t, os.Rename(fileName, fileName+".1"))
+
+	require.NoError(t, logg.Reopen())
+	logg.Info("after rotation")
+
+	rotated, err := os.ReadFile(fileName + ".1")
+	require.NoError(t, err)
+	assert.Contains(t, string(rotated), "before rotation")
+	assert.NotContains(t, string(rotated), "after rotation")
+
+	current, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "after rotation")
+}
+
+func TestLogger_UnknownOutput(t *testing.T) {
+	_, err := New(config.LoggerConf{Level: "info", Output: "syslog"})
+	assert.Error(t, err)
+}
+
+func TestLogger_ContextFields(t *testing.T) {
+	logg, err := New(config.LoggerConf{Level: "info", Format: FormatJSON, Output: OutputStdout})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	logg.SetOutput(&buf)
+
+	ctx := WithField(context.Background(), FieldRequestID, "req-1")
+	ctx = WithFields(ctx, Fields{FieldUserID: 7, FieldEventID: 3})
+	logg.InfoContext(ctx, "event created")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "event created", entry["msg"])
+	assert.Equal(t, "req-1", entry[FieldRequestID])
+	assert.EqualValues(t, 7, entry[FieldUserID])
+	assert.EqualValues(t, 3, entry[FieldEventID])
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
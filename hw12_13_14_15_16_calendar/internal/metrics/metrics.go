@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "calendar"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+	cacheLookups    *prometheus.CounterVec
+
+	reminders     *prometheus.CounterVec
+	notifications *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "gRPC request latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Storage operation latency by backend and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_errors_total",
+			Help:      "Number of failed storage operations by backend and operation.",
+		}, []string{"backend", "operation"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_cache_lookups_total",
+			Help:      "Number of storage cache lookups by operation and result (hit or miss).",
+		}, []string{"operation", "result"}),
+		reminders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scheduler_reminders_total",
+			Help:      "Number of reminders processed by the scheduler by result (success, skipped or error).",
+		}, []string{"result"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sender_notifications_total",
+			Help:      "Number of notifications sent by channel and result.",
+		}, []string{"channel", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcRequests,
+		m.grpcDuration,
+		m.storageDuration,
+		m.storageErrors,
+		m.cacheLookups,
+		m.reminders,
+		m.notifications,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDBStats добавляет статистику пула соединений (go_sql_*) с меткой db_name.
+func (m *Metrics) RegisterDBStats(name string, db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterEventsGauge добавляет gauge с количеством событий, вычисляемый при каждом scrape.
+func (m *Metrics) RegisterEventsGauge(count func() (int, error)) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "events",
+		Help:      "Number of stored events.",
+	}, func() float64 {
+		n, err := count()
+		if err != nil {
+			return 0
+		}
+		return float64(n)
+	}))
+}
+
+func (m *Metrics) ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveGRPCRequest(method, code string, duration time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveStorageOperation(backend, operation string, duration time.Duration, err error) {
+	m.storageDuration.WithLabelValues(backend, operation).Observe(duration.Seconds())
+	if err != nil {
+		m.storageErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+func (m *Metrics) ObserveCacheLookup(operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(operation, result).Inc()
+}
+
+// ObserveReminder учитывает напоминание, которое уже отправил другой экземпляр, как пропущенное.
+func (m *Metrics) ObserveReminder(err error) {
+	if errors.Is(err, domain.ErrReminderAlreadySent) {
+		m.reminders.WithLabelValues("skipped").Inc()
+		return
+	}
+	m.reminders.WithLabelValues(result(err)).Inc()
+}
+
+func (m *Metrics) ObserveNotification(channel string, err error) {
+	m.notifications.WithLabelValues(channel, result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Logger interface {
+	DebugContext(ctx context.Context, args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
+}
+
+// Storage оборачивает хранилище, замеряет длительность и ошибки каждой операции
+// и пишет их в лог с полями запроса из контекста.
+type Storage struct {
+	storage.Storage
+	metrics *Metrics
+	logger  Logger
+	backend string
+}
+
+func InstrumentStorage(s storage.Storage, m *Metrics, logger Logger, backend string) *Storage {
+	return &Storage{Storage: s, metrics: m, logger: logger, backend: backend}
+}
+
+func (s *Storage) observe(ctx context.Context, operation string, start time.Time, err error) {
+	duration := time.Since(start)
+
+	// Отсутствие записи — штатный результат, а не сбой хранилища.
+	if errors.Is(err, domain.ErrEventNotFound) || errors.Is(err, domain.ErrWebhookNotFound) ||
+		errors.Is(err, domain.ErrChannelNotFound) || errors.Is(err, domain.ErrReminderNotFound) ||
+		errors.Is(err, domain.ErrIdempotencyKeyNotFound) || errors.Is(err, domain.ErrReminderAlreadySent) {
+		err = nil
+	}
+	s.metrics.ObserveStorageOperation(s.backend, operation, duration, err)
+
+	ctx = logger.WithFields(ctx, logger.Fields{
+		"storage_backend":   s.backend,
+		"storage_operation": operation,
+		"latency_ms":        duration.Milliseconds(),
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "storage operation failed: "+err.Error())
+		return
+	}
+	s.logger.DebugContext(ctx, "storage operation")
+}
+
+// withEventID добавляет в поля лога ID события, если он уже известен.
+func withEventID(ctx context.Context, id int) context.Context {
+	if id <= 0 {
+		return ctx
+	}
+	return logger.WithField(ctx, logger.FieldEventID, id)
+}
+
+func (s *Storage) Event() storage.EventRepository {
+	return &eventRepository{repo: s.Storage.Event(), s: s}
+}
+
+func (s *Storage) Webhook() storage.WebhookRepository {
+	return &webhookRepository{repo: s.Storage.Webhook(), s: s}
+}
+
+func (s *Storage) Channel() storage.ChannelRepository {
+	return &channelRepository{repo: s.Storage.Channel(), s: s}
+}
+
+func (s *Storage) Reminder() storage.ReminderRepository {
+	return &reminderRepository{repo: s.Storage.Reminder(), s: s}
+}
+
+func (s *Storage) Idempotency() storage.IdempotencyRepository {
+	return &idempotencyRepository{repo: s.Storage.Idempotency(), s: s}
+}
+
+func (s *Storage) Outbox() storage.OutboxRepository {
+	return &outboxRepository{repo: s.Storage.Outbox(), s: s}
+}
+
+type eventRepository struct {
+	repo storage.EventRepository
+	s    *Storage
+}
+
+func (r *eventRepository) Create(ctx context.Context, e *domain.Event) (err error) {
+	defer func(start time.Time) { r.s.observe(withEventID(ctx, e.ID), "event_create", start, err) }(time.Now())
+	return r.repo.Create(ctx, e)
+}
+
+func (r *eventRepository) Update(ctx context.Context, id int, e *domain.Event) (err error) {
+	defer func(start time.Time) { r.s.observe(withEventID(ctx, id), "event_update", start, err) }(time.Now())
+	return r.repo.Update(ctx, id, e)
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id int) (err error) {
+	defer func(start time.Time) { r.s.observe(withEventID(ctx, id), "event_delete", start, err) }(time.Now())
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *eventRepository) Get(ctx context.Context, id int) (_ domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(withEventID(ctx, id), "event_get", start, err) }(time.Now())
+	return r.repo.Get(ctx, id)
+}
+
+func (r *eventRepository) ListByDay(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_list_by_day", start, err) }(time.Now())
+	return r.repo.ListByDay(ctx, userID, date, tags)
+}
+
+func (r *eventRepository) ListByWeek(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_list_by_week", start, err) }(time.Now())
+	return r.repo.ListByWeek(ctx, userID, date, tags)
+}
+
+func (r *eventRepository) ListByMonth(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_list_by_month", start, err) }(time.Now())
+	return r.repo.ListByMonth(ctx, userID, date, tags)
+}
+
+func (r *eventRepository) ListAfter(ctx context.Context, userID, afterID, limit int) (_ []domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_list_after", start, err) }(time.Now())
+	return r.repo.ListAfter(ctx, userID, afterID, limit)
+}
+
+func (r *eventRepository) Restore(ctx context.Context, e *domain.Event) (err error) {
+	defer func(start time.Time) { r.s.observe(withEventID(ctx, e.ID), "event_restore", start, err) }(time.Now())
+	return r.repo.Restore(ctx, e)
+}
+
+func (r *eventRepository) ListRestored(ctx context.Context, sourceID int) (_ []domain.Event, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_list_restored", start, err) }(time.Now())
+	return r.repo.ListRestored(ctx, sourceID)
+}
+
+func (r *eventRepository) Count(ctx context.Context) (_ int, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_count", start, err) }(time.Now())
+	return r.repo.Count(ctx)
+}
+
+func (r *eventRepository) Search(ctx context.Context, q domain.SearchQuery) (_ []domain.SearchResult, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_search", start, err) }(time.Now())
+	return r.repo.Search(ctx, q)
+}
+
+func (r *eventRepository) TagCounts(ctx context.Context, userID int) (_ []domain.TagCount, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_tag_counts", start, err) }(time.Now())
+	return r.repo.TagCounts(ctx, userID)
+}
+
+func (r *eventRepository) CreateBatch(ctx context.Context, events []*domain.Event) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_create_batch", start, err) }(time.Now())
+	return r.repo.CreateBatch(ctx, events)
+}
+
+func (r *eventRepository) UpdateBatch(
+	ctx context.Context, userID int, events []*domain.Event, mode domain.BatchMode,
+) (_ []error, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_update_batch", start, err) }(time.Now())
+	return r.repo.UpdateBatch(ctx, userID, events, mode)
+}
+
+func (r *eventRepository) DeleteBatch(
+	ctx context.Context, userID int, ids []int, mode domain.BatchMode,
+) (_ []domain.Event, _ []error, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "event_delete_batch", start, err) }(time.Now())
+	return r.repo.DeleteBatch(ctx, userID, ids, mode)
+}
+
+type webhookRepository struct {
+	repo storage.WebhookRepository
+	s    *Storage
+}
+
+func (r *webhookRepository) Create(ctx context.Context, w *domain.Webhook) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_create", start, err) }(time.Now())
+	return r.repo.Create(ctx, w)
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id int) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_delete", start, err) }(time.Now())
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *webhookRepository) Get(ctx context.Context, id int) (_ domain.Webhook, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_get", start, err) }(time.Now())
+	return r.repo.Get(ctx, id)
+}
+
+func (r *webhookRepository) ListByUser(ctx context.Context, userID int) (_ []domain.Webhook, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_list_by_user", start, err) }(time.Now())
+	return r.repo.ListByUser(ctx, userID)
+}
+
+func (r *webhookRepository) AddDelivery(ctx context.Context, d *domain.WebhookDelivery) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_add_delivery", start, err) }(time.Now())
+	return r.repo.AddDelivery(ctx, d)
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID int) (_ []domain.WebhookDelivery, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "webhook_list_deliveries", start, err) }(time.Now())
+	return r.repo.ListDeliveries(ctx, webhookID)
+}
+
+type channelRepository struct {
+	repo storage.ChannelRepository
+	s    *Storage
+}
+
+func (r *channelRepository) Get(ctx context.Context, userID int) (_ domain.NotificationChannel, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "channel_get", start, err) }(time.Now())
+	return r.repo.Get(ctx, userID)
+}
+
+func (r *channelRepository) Set(ctx context.Context, c *domain.NotificationChannel) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "channel_set", start, err) }(time.Now())
+	return r.repo.Set(ctx, c)
+}
+
+func (r *channelRepository) Delete(ctx context.Context, userID int) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "channel_delete", start, err) }(time.Now())
+	return r.repo.Delete(ctx, userID)
+}
+
+type reminderRepository struct {
+	repo storage.ReminderRepository
+	s    *Storage
+}
+
+func (r *reminderRepository) ListDue(ctx context.Context, now time.Time) (_ []domain.Reminder, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "reminder_list_due", start, err) }(time.Now())
+	return r.repo.ListDue(ctx, now)
+}
+
+func (r *reminderRepository) Fire(
+	ctx context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage,
+) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "reminder_fire", start, err) }(time.Now())
+	return r.repo.Fire(ctx, id, sentAt, msg)
+}
+
+type idempotencyRepository struct {
+	repo storage.IdempotencyRepository
+	s    *Storage
+}
+
+func (r *idempotencyRepository) Begin(
+	ctx context.Context, rec domain.IdempotencyRecord,
+) (_ domain.IdempotencyRecord, _ bool, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "idempotency_begin", start, err) }(time.Now())
+	return r.repo.Begin(ctx, rec)
+}
+
+func (r *idempotencyRepository) Complete(
+	ctx context.Context, userID int, key, owner string, response []byte, expiresAt time.Time,
+) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "idempotency_complete", start, err) }(time.Now())
+	return r.repo.Complete(ctx, userID, key, owner, response, expiresAt)
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, userID int, key, owner string) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "idempotency_delete", start, err) }(time.Now())
+	return r.repo.Delete(ctx, userID, key, owner)
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (_ int, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "idempotency_delete_expired", start, err) }(time.Now())
+	return r.repo.DeleteExpired(ctx, now)
+}
+
+type outboxRepository struct {
+	repo storage.OutboxRepository
+	s    *Storage
+}
+
+func (r *outboxRepository) ClaimPending(
+	ctx context.Context, owner string, now, until time.Time, limit int,
+) (_ []domain.OutboxMessage, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "outbox_claim_pending", start, err) }(time.Now())
+	return r.repo.ClaimPending(ctx, owner, now, until, limit)
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, ids []int, dispatchedAt time.Time) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "outbox_mark_dispatched", start, err) }(time.Now())
+	return r.repo.MarkDispatched(ctx, ids, dispatchedAt)
+}
+
+func (r *outboxRepository) MarkProcessed(ctx context.Context, id int, processedAt time.Time) (err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "outbox_mark_processed", start, err) }(time.Now())
+	return r.repo.MarkProcessed(ctx, id, processedAt)
+}
+
+func (r *outboxRepository) Processed(ctx context.Context, id int) (_ bool, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "outbox_processed", start, err) }(time.Now())
+	return r.repo.Processed(ctx, id)
+}
+
+func (r *outboxRepository) DeleteDispatched(ctx context.Context, before time.Time) (_ int, err error) {
+	defer func(start time.Time) { r.s.observe(ctx, "outbox_delete_dispatched", start, err) }(time.Now())
+	return r.repo.DeleteDispatched(ctx, before)
+}
@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type HTTPNotifier struct {
+	client *http.Client
+}
+
+type httpPayload struct {
+	Subject      string              `json:"subject"`
+	Body         string              `json:"body"`
+	Notification domain.Notification `json:"notification"`
+}
+
+func NewHTTPNotifier(conf config.HTTPNotifierConf) *HTTPNotifier {
+	return &HTTPNotifier{client: &http.Client{Timeout: conf.Timeout}}
+}
+
+func (n *HTTPNotifier) Notify(ctx context.Context, address string, msg Message) error {
+	body, err := json.Marshal(httpPayload{
+		Subject:      msg.Subject,
+		Body:         msg.Body,
+		Notification: msg.Notification,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+type LogNotifier struct {
+	logger Logger
+}
+
+func NewLogNotifier(logger Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, _ string, msg Message) error {
+	n.logger.Info(fmt.Sprintf("notification for user %d about event %d: %s",
+		msg.Notification.UserID, msg.Notification.EventID, msg.Subject))
+	return nil
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+type Storage interface {
+	Channel() storage.ChannelRepository
+}
+
+type Metrics interface {
+	ObserveNotification(channel string, err error)
+}
+
+type Message struct {
+	Subject      string
+	Body         string
+	Notification domain.Notification
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, address string, msg Message) error
+}
+
+// Sender выбирает канал доставки по настройкам пользователя.
+// Если пользователь канал не настраивал, уведомление пишется в лог.
+type Sender struct {
+	logger    Logger
+	storage   Storage
+	renderer  *Renderer
+	metrics   Metrics
+	notifiers map[domain.ChannelType]Notifier
+}
+
+func NewSender(logger Logger, storage Storage, metrics Metrics, conf config.NotifierConf) (*Sender, error) {
+	renderer, err := NewRenderer(conf.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sender{
+		logger:   logger,
+		storage:  storage,
+		renderer: renderer,
+		metrics:  metrics,
+		notifiers: map[domain.ChannelType]Notifier{
+			domain.ChannelLog:   NewLogNotifier(logger),
+			domain.ChannelEmail: NewSMTPNotifier(conf.SMTP),
+			domain.ChannelHTTP:  NewHTTPNotifier(conf.HTTP),
+		},
+	}, nil
+}
+
+func (s *Sender) Send(ctx context.Context, n domain.Notification) error {
+	channel, err := s.storage.Channel().Get(ctx, n.UserID)
+	if errors.Is(err, domain.ErrChannelNotFound) {
+		channel = domain.NotificationChannel{UserID: n.UserID, Type: domain.ChannelLog}
+	} else if err != nil {
+		return fmt.Errorf("failed to get notification channel: %w", err)
+	}
+
+	notifier, ok := s.notifiers[channel.Type]
+	if !ok {
+		return domain.ErrUnknownChannelType
+	}
+
+	msg, err := s.renderer.Render(n)
+	if err != nil {
+		return err
+	}
+
+	err = notifier.Notify(ctx, channel.Address, msg)
+	s.metrics.ObserveNotification(string(channel.Type), err)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", channel.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveNotification(string, error) {}
+
+type smtpMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer принимает одно письмо и отдаёт его в канал.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan smtpMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan smtpMail, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var mail smtpMail
+
+		_ = tp.PrintfLine("220 localhost fake smtp")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case "MAIL":
+				mail.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+				_ = tp.PrintfLine("250 OK")
+			case "RCPT":
+				mail.to = append(mail.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				mail.data = string(data)
+				_ = tp.PrintfLine("250 OK")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				mails <- mail
+				return
+			default:
+				_ = tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return host, port, mails
+}
+
+func testNotification() domain.Notification {
+	return domain.Notification{
+		EventID:     42,
+		Title:       "Budget meeting",
+		EventTime:   time.Date(2025, 11, 5, 10, 30, 0, 0, time.UTC),
+		Description: "Discuss Q4 budget",
+		UserID:      1,
+	}
+}
+
+func TestRenderer_DefaultTemplate(t *testing.T) {
+	renderer, err := NewRenderer("")
+	require.NoError(t, err)
+
+	msg, err := renderer.Render(testNotification())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Reminder: Budget meeting", msg.Subject)
+	assert.Contains(t, msg.Body, "2025-11-05 10:30 UTC")
+	assert.Contains(t, msg.Body, "Discuss Q4 budget")
+}
+
+func TestSMTPNotifier_SendsMail(t *testing.T) {
+	host, port, mails := fakeSMTPServer(t)
+
+	notifier := NewSMTPNotifier(config.SMTPConf{Host: host, Port: port, From: "calendar@localhost"})
+
+	renderer, err := NewRenderer("")
+	require.NoError(t, err)
+	msg, err := renderer.Render(testNotification())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, notifier.Notify(ctx, "user@example.com", msg))
+
+	mail := <-mails
+	assert.Equal(t, "calendar@localhost", mail.from)
+	assert.Equal(t, []string{"user@example.com"}, mail.to)
+
+	tp := textproto.NewReader(bufio.NewReader(strings.NewReader(mail.data)))
+	header, err := tp.ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: Budget meeting", header.Get("Subject"))
+	assert.Contains(t, mail.data, "Discuss Q4 budget")
+}
+
+func TestSender_RoutesByUserChannel(t *testing.T) {
+	received := make(chan httpPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload httpPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	require.NoError(t, storage.Channel().Set(context.Background(), &domain.NotificationChannel{
+		UserID:  1,
+		Type:    domain.ChannelHTTP,
+		Address: server.URL,
+	}))
+
+	sender, err := NewSender(nopLogger{}, storage, nopMetrics{}, config.NotifierConf{
+		HTTP: config.HTTPNotifierConf{Timeout: time.Second},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(context.Background(), testNotification()))
+
+	payload := <-received
+	assert.Equal(t, "Reminder: Budget meeting", payload.Subject)
+	assert.Equal(t, 42, payload.Notification.EventID)
+
+	// Пользователь без настроенного канала получает уведомление в лог.
+	other := testNotification()
+	other.UserID = 2
+	require.NoError(t, sender.Send(context.Background(), other))
+}
+
+func TestSender_HTTPErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	require.NoError(t, storage.Channel().Set(context.Background(), &domain.NotificationChannel{
+		UserID:  1,
+		Type:    domain.ChannelHTTP,
+		Address: server.URL,
+	}))
+
+	sender, err := NewSender(nopLogger{}, storage, nopMetrics{}, config.NotifierConf{})
+	require.NoError(t, err)
+
+	assert.Error(t, sender.Send(context.Background(), testNotification()))
+}
@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+)
+
+type SMTPNotifier struct {
+	conf config.SMTPConf
+}
+
+func NewSMTPNotifier(conf config.SMTPConf) *SMTPNotifier {
+	return &SMTPNotifier{conf: conf}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, address string, msg Message) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.conf.Host, n.conf.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, n.conf.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.conf.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if n.conf.Username != "" {
+		auth := smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.conf.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.buildMessage(address, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) buildMessage(address string, msg Message) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + n.conf.From + "\r\n")
+	b.WriteString("To: " + address + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
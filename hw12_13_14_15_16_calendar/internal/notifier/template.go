@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+const defaultTemplate = `{{define "subject"}}Reminder: {{.Title}}{{end}}
+{{define "body"}}{{.Title}}
+When: {{.EventTime.Format "2006-01-02 15:04 MST"}}
+{{with .Description}}
+{{.}}
+{{end}}{{end}}`
+
+type Renderer struct {
+	tmpl *template.Template
+}
+
+// NewRenderer загружает шаблон из файла. Шаблон должен определять блоки "subject" и "body".
+// При пустом пути используется встроенный шаблон.
+func NewRenderer(path string) (*Renderer, error) {
+	var (
+		tmpl *template.Template
+		err  error
+	)
+
+	if path == "" {
+		tmpl, err = template.New("notification").Parse(defaultTemplate)
+	} else {
+		tmpl, err = template.ParseFiles(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notification template: %w", err)
+	}
+
+	for _, name := range []string{"subject", "body"} {
+		if tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("notification template must define %q", name)
+		}
+	}
+
+	return &Renderer{tmpl: tmpl}, nil
+}
+
+func (r *Renderer) Render(n domain.Notification) (Message, error) {
+	var subject, body bytes.Buffer
+
+	if err := r.tmpl.ExecuteTemplate(&subject, "subject", n); err != nil {
+		return Message{}, fmt.Errorf("failed to render notification subject: %w", err)
+	}
+	if err := r.tmpl.ExecuteTemplate(&body, "body", n); err != nil {
+		return Message{}, fmt.Errorf("failed to render notification body: %w", err)
+	}
+
+	return Message{
+		Subject:      strings.TrimSpace(subject.String()),
+		Body:         body.String(),
+		Notification: n,
+	}, nil
+}
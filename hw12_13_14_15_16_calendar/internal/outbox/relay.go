@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+type Storage interface {
+	Outbox() storage.OutboxRepository
+}
+
+type Queue interface {
+	Publish(ctx context.Context, msg domain.OutboxMessage) error
+}
+
+// Relay захватывает пачки сообщений outbox, публикует их в очередь по порядку ID и помечает
+// опубликованными, когда очередь приняла сообщение (queue.Memory — когда получатель его
+// обработал). Захват не даёт нескольким экземплярам публиковать одни и те же сообщения.
+// Если процесс упадёт между публикацией и отметкой, после истечения захвата сообщение
+// опубликуется ещё раз, поэтому доставка — не меньше одного раза.
+type Relay struct {
+	logger  Logger
+	storage Storage
+	queue   Queue
+	conf    config.OutboxConf
+	now     func() time.Time
+	// owner отличает захваты этого экземпляра от чужих.
+	owner string
+
+	// published — опубликованные сообщения, отметку которых ещё не удалось сохранить.
+	// Пока процесс жив, они не публикуются повторно.
+	published map[int]struct{}
+}
+
+func New(logger Logger, storage Storage, queue Queue, conf config.OutboxConf) *Relay {
+	if conf.PollInterval <= 0 {
+		conf.PollInterval = time.Second
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 100
+	}
+	if conf.Retention <= 0 {
+		conf.Retention = 24 * time.Hour
+	}
+	if conf.CleanupInterval <= 0 {
+		conf.CleanupInterval = 10 * time.Minute
+	}
+	if conf.ClaimTimeout <= 0 {
+		conf.ClaimTimeout = 5 * time.Minute
+	}
+
+	return &Relay{
+		logger:    logger,
+		storage:   storage,
+		queue:     queue,
+		conf:      conf,
+		now:       time.Now,
+		owner:     newOwner(),
+		published: make(map[int]struct{}),
+	}
+}
+
+// Run публикует новые сообщения и удаляет старые опубликованные до отмены ctx.
+func (r *Relay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.conf.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.conf.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			if _, err := r.Dispatch(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error(fmt.Sprintf("failed to dispatch outbox: %v", err))
+			}
+		case <-cleanup.C:
+			r.Cleanup(ctx)
+		}
+	}
+}
+
+// Dispatch публикует неопубликованные сообщения пачками по BatchSize, пока они не кончатся.
+// Сообщения, захваченные другим экземпляром, пропускаются.
+// На первой ошибке публикации останавливается, чтобы не нарушить порядок, и возвращает
+// число опубликованных сообщений.
+func (r *Relay) Dispatch(ctx context.Context) (int, error) {
+	if err := r.markPublished(ctx); err != nil {
+		return 0, err
+	}
+
+	var total int
+	for {
+		now := r.now().UTC()
+		messages, err := r.storage.Outbox().ClaimPending(ctx, r.owner, now, now.Add(r.conf.ClaimTimeout),
+			r.conf.BatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, msg := range messages {
+			if err := r.queue.Publish(ctx, msg); err != nil {
+				err = fmt.Errorf("publish outbox message %d: %w", msg.ID, err)
+				return total, errors.Join(err, r.markPublished(ctx))
+			}
+			r.published[msg.ID] = struct{}{}
+			total++
+		}
+
+		if err := r.markPublished(ctx); err != nil {
+			return total, err
+		}
+		if len(messages) < r.conf.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// markPublished сохраняет отметки даже при отмене ctx: сообщения уже в очереди.
+func (r *Relay) markPublished(ctx context.Context) error {
+	if len(r.published) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(r.published))
+	for id := range r.published {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	err := r.storage.Outbox().MarkDispatched(context.WithoutCancel(ctx), ids, r.now().UTC())
+	if err != nil {
+		return fmt.Errorf("mark outbox messages dispatched: %w", err)
+	}
+	clear(r.published)
+	return nil
+}
+
+func (r *Relay) Cleanup(ctx context.Context) {
+	n, err := r.storage.Outbox().DeleteDispatched(ctx, r.now().UTC().Add(-r.conf.Retention))
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("failed to delete dispatched outbox messages: %v", err))
+		return
+	}
+	if n > 0 {
+		r.logger.Info(fmt.Sprintf("deleted %d dispatched outbox messages", n))
+	}
+}
+
+func newOwner() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
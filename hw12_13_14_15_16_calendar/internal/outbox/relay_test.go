@@ -0,0 +1,447 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errCrash = errors.New("crash")
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})                          {}
+func (nopLogger) Error(...interface{})                         {}
+func (nopLogger) Debug(...interface{})                         {}
+func (nopLogger) Warn(...interface{})                          {}
+func (nopLogger) InfoContext(context.Context, ...interface{})  {}
+func (nopLogger) ErrorContext(context.Context, ...interface{}) {}
+
+type recordingLogger struct {
+	nopLogger
+	infos []string
+}
+
+func (l *recordingLogger) Info(args ...interface{}) {
+	l.infos = append(l.infos, fmt.Sprint(args...))
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, domain.EventAction, domain.Event) {}
+
+// recordingSender запоминает отправленные уведомления; первые failures попыток отправки
+// завершаются ошибкой, после каждой из них вызывается onFailure.
+type recordingSender struct {
+	mu        sync.Mutex
+	sent      []domain.Notification
+	attempts  int
+	failures  int
+	onFailure func()
+}
+
+func (s *recordingSender) Send(_ context.Context, n domain.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	if s.attempts <= s.failures {
+		if s.onFailure != nil {
+			s.onFailure()
+		}
+		return errCrash
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *recordingSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+// memoryQueue запоминает опубликованные сообщения; failAt номер публикации (с 1), которая упадёт.
+type memoryQueue struct {
+	messages []domain.OutboxMessage
+	calls    int
+	failAt   int
+}
+
+func (q *memoryQueue) Publish(_ context.Context, msg domain.OutboxMessage) error {
+	q.calls++
+	if q.calls == q.failAt {
+		return errCrash
+	}
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+func (q *memoryQueue) ids() []int {
+	ids := make([]int, len(q.messages))
+	for i, msg := range q.messages {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+// faultyStorage имитирует падение процесса в точках, где транзакция не успевает завершиться,
+// и запоминает сообщения, поставленные в outbox и помеченные опубликованными.
+type faultyStorage struct {
+	*memorystorage.Storage
+	failFire   bool
+	failMark   bool
+	fired      map[int]bool
+	dispatched map[int]bool
+}
+
+func (s *faultyStorage) Reminder() storage.ReminderRepository {
+	return &faultyReminders{ReminderRepository: s.Storage.Reminder(), s: s}
+}
+
+func (s *faultyStorage) Outbox() storage.OutboxRepository {
+	return &faultyOutbox{OutboxRepository: s.Storage.Outbox(), s: s}
+}
+
+type faultyReminders struct {
+	storage.ReminderRepository
+	s *faultyStorage
+}
+
+func (r *faultyReminders) Fire(ctx context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage) error {
+	if r.s.failFire {
+		return errCrash
+	}
+	if err := r.ReminderRepository.Fire(ctx, id, sentAt, msg); err != nil {
+		return err
+	}
+	r.s.fired[msg.ID] = true
+	return nil
+}
+
+type faultyOutbox struct {
+	storage.OutboxRepository
+	s *faultyStorage
+}
+
+func (o *faultyOutbox) MarkDispatched(ctx context.Context, ids []int, at time.Time) error {
+	if o.s.failMark {
+		return errCrash
+	}
+	if err := o.OutboxRepository.MarkDispatched(ctx, ids, at); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		o.s.dispatched[id] = true
+	}
+	return nil
+}
+
+type fixture struct {
+	storage *faultyStorage
+	app     *app.App
+	now     time.Time
+}
+
+// newFixture создаёт count событий, напоминания которых уже наступили.
+func newFixture(t *testing.T, count int) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		storage: &faultyStorage{
+			Storage:    memorystorage.NewStorage(),
+			fired:      make(map[int]bool),
+			dispatched: make(map[int]bool),
+		},
+		now: time.Now().UTC(),
+	}
+	f.app = app.New(nopLogger{}, f.storage, nopPublisher{}, &recordingSender{})
+
+	for i := 0; i < count; i++ {
+		event := &domain.Event{
+			Title:     "Standup",
+			EventTime: f.now.Add(time.Duration(i+1) * time.Minute),
+			Duration:  time.Hour,
+			UserID:    1,
+			Reminders: []domain.Reminder{{Offset: time.Hour}},
+		}
+		require.NoError(t, f.storage.Event().Create(context.Background(), event))
+	}
+	return f
+}
+
+// tick повторяет один проход планировщика.
+func (f *fixture) tick(t *testing.T) []error {
+	t.Helper()
+
+	reminders, err := f.app.ListDueReminders(context.Background(), f.now)
+	require.NoError(t, err)
+
+	var errs []error
+	for _, reminder := range reminders {
+		errs = append(errs, f.app.SendReminder(context.Background(), reminder))
+	}
+	return errs
+}
+
+// newQueue создаёт очередь с короткими паузами между попытками обработки.
+func newQueue() *queue.Memory {
+	return queue.NewMemory(nopLogger{}, config.OutboxConf{
+		MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+	})
+}
+
+func (f *fixture) relay(q Queue, batchSize int) *Relay {
+	return New(nopLogger{}, f.storage, q, config.OutboxConf{BatchSize: batchSize, ClaimTimeout: time.Minute})
+}
+
+// afterClaim — момент, когда захваты, сделанные сейчас, уже истекли.
+func afterClaim() time.Time {
+	return time.Now().Add(time.Hour)
+}
+
+func (f *fixture) pending() int {
+	return len(f.storage.fired) - len(f.storage.dispatched)
+}
+
+func TestRelay_CrashBeforeFire(t *testing.T) {
+	f := newFixture(t, 1)
+
+	// Транзакция не завершилась: ни отметки о напоминании, ни сообщения.
+	f.storage.failFire = true
+	assert.ErrorIs(t, errors.Join(f.tick(t)...), errCrash)
+	assert.Zero(t, f.pending())
+
+	// После перезапуска напоминание всё ещё наступившее и ставится в outbox один раз.
+	f.storage.failFire = false
+	assert.NoError(t, errors.Join(f.tick(t)...))
+	assert.Empty(t, f.tick(t))
+	assert.Equal(t, 1, f.pending())
+}
+
+func TestRelay_CrashBeforeDispatch(t *testing.T) {
+	f := newFixture(t, 2)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	// Процесс упал до публикации: напоминания уже не наступившие, но сообщения не потеряны.
+	assert.Empty(t, f.tick(t))
+
+	q := &memoryQueue{}
+	n, err := f.relay(q, 10).Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var notification domain.Notification
+	require.NoError(t, json.Unmarshal(q.messages[0].Payload, &notification))
+	assert.Equal(t, "Standup", notification.Title)
+	assert.Equal(t, domain.TopicNotifications, q.messages[0].Topic)
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_CrashAfterPublish(t *testing.T) {
+	f := newFixture(t, 2)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	q := &memoryQueue{}
+	f.storage.failMark = true
+	_, err := f.relay(q, 10).Dispatch(context.Background())
+	require.ErrorIs(t, err, errCrash)
+	require.Len(t, q.messages, 2)
+	assert.Equal(t, 2, f.pending())
+
+	// Пока захват упавшего процесса не истёк, новый процесс сообщения не берёт.
+	f.storage.failMark = false
+	relay := f.relay(q, 10)
+	n, err := relay.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Затем публикует их повторно с теми же ID, по которым получатель отсеет дубли.
+	relay.now = afterClaim
+	n, err = relay.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{1, 2, 1, 2}, q.ids())
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_ClaimedByAnotherRelay(t *testing.T) {
+	f := newFixture(t, 3)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	// Первый экземпляр захватил пачку и застрял на первом сообщении.
+	first := f.relay(&memoryQueue{failAt: 1}, 2)
+	_, err := first.Dispatch(context.Background())
+	require.ErrorIs(t, err, errCrash)
+
+	// Второй не трогает захваченную пачку и публикует остальное.
+	q := &memoryQueue{}
+	n, err := f.relay(q, 2).Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int{3}, q.ids())
+
+	// Свою пачку первый экземпляр получает повторно, не дожидаясь истечения захвата.
+	q = &memoryQueue{}
+	first.queue = q
+	n, err = first.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{1, 2}, q.ids())
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_MarkRetriedWithoutRepublish(t *testing.T) {
+	f := newFixture(t, 2)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	q := &memoryQueue{}
+	relay := f.relay(q, 10)
+
+	f.storage.failMark = true
+	_, err := relay.Dispatch(context.Background())
+	require.ErrorIs(t, err, errCrash)
+
+	// Пока отметка не сохранена, новые сообщения не публикуются.
+	_, err = relay.Dispatch(context.Background())
+	require.ErrorIs(t, err, errCrash)
+
+	f.storage.failMark = false
+	n, err := relay.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []int{1, 2}, q.ids())
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_PublishFailureKeepsOrder(t *testing.T) {
+	f := newFixture(t, 5)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	q := &memoryQueue{failAt: 3}
+	relay := f.relay(q, 2)
+
+	n, err := relay.Dispatch(context.Background())
+	require.ErrorIs(t, err, errCrash)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, f.pending())
+
+	n, err = relay.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, q.ids())
+}
+
+func TestRelay_Cleanup(t *testing.T) {
+	f := newFixture(t, 1)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	logger := &recordingLogger{}
+	relay := New(logger, f.storage, &memoryQueue{}, config.OutboxConf{Retention: time.Hour})
+	_, err := relay.Dispatch(context.Background())
+	require.NoError(t, err)
+
+	relay.Cleanup(context.Background())
+	assert.Empty(t, logger.infos)
+
+	relay.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	relay.Cleanup(context.Background())
+	assert.Equal(t, []string{"deleted 1 dispatched outbox messages"}, logger.infos)
+}
+
+func TestRelay_DeliversThroughQueue(t *testing.T) {
+	f := newFixture(t, 3)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	sender := &recordingSender{}
+	calendar := app.New(nopLogger{}, f.storage, nopPublisher{}, sender)
+	q := newQueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, calendar.HandleNotification)
+
+	n, err := f.relay(q, 10).Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 3, sender.count())
+}
+
+func TestRelay_RedeliversFailedSend(t *testing.T) {
+	f := newFixture(t, 2)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	sender := &recordingSender{failures: 2}
+	calendar := app.New(nopLogger{}, f.storage, nopPublisher{}, sender)
+	q := newQueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, calendar.HandleNotification)
+
+	// Первое уведомление отправляется с третьей попытки, и только потом сообщения
+	// помечаются опубликованными.
+	n, err := f.relay(q, 10).Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 4, sender.attempts)
+	assert.Equal(t, 2, sender.count())
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_RedeliveryNotSentTwice(t *testing.T) {
+	f := newFixture(t, 2)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	sender := &recordingSender{}
+	q := newQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, app.New(nopLogger{}, f.storage, nopPublisher{}, sender).HandleNotification)
+
+	// Уведомления отправлены, но процесс упал, не отметив сообщения опубликованными.
+	f.storage.failMark = true
+	_, err := f.relay(q, 10).Dispatch(ctx)
+	require.ErrorIs(t, err, errCrash)
+	require.Equal(t, 2, sender.count())
+
+	// Новый процесс публикует их повторно, получатель узнаёт их по ID и не отправляет снова.
+	f.storage.failMark = false
+	relay := f.relay(q, 10)
+	relay.now = afterClaim
+	n, err := relay.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, sender.attempts)
+	assert.Equal(t, 2, sender.count())
+	assert.Zero(t, f.pending())
+}
+
+func TestRelay_StopBeforeAck(t *testing.T) {
+	f := newFixture(t, 1)
+	require.NoError(t, errors.Join(f.tick(t)...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := &recordingSender{failures: 1, onFailure: cancel}
+	q := newQueue()
+	go q.Consume(ctx, app.New(nopLogger{}, f.storage, nopPublisher{}, sender).HandleNotification)
+
+	// Процесс останавливается, пока уведомление не отправлено: сообщение не помечается
+	// опубликованным и после перезапуска будет опубликовано снова.
+	_, err := f.relay(q, 10).Dispatch(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, f.pending())
+}
@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type Logger interface {
+	Warn(args ...interface{})
+	Error(args ...interface{})
+}
+
+// Handler обрабатывает сообщение; повторы одного сообщения приходят с тем же ID.
+type Handler func(ctx context.Context, msg domain.OutboxMessage) error
+
+// Memory — очередь в памяти процесса для работы без брокера. Publish возвращает управление
+// только после обработки сообщения, поэтому relay помечает сообщение опубликованным не раньше,
+// чем получатель его подтвердит. Если процесс упадёт раньше, сообщение опубликуется снова.
+type Memory struct {
+	logger     Logger
+	conf       config.OutboxConf
+	deliveries chan delivery
+}
+
+type delivery struct {
+	msg domain.OutboxMessage
+	// done закрывается, когда получатель закончил с сообщением.
+	done chan struct{}
+}
+
+func NewMemory(logger Logger, conf config.OutboxConf) *Memory {
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = 1
+	}
+	if conf.InitialBackoff <= 0 {
+		conf.InitialBackoff = time.Second
+	}
+	if conf.MaxBackoff < conf.InitialBackoff {
+		conf.MaxBackoff = conf.InitialBackoff
+	}
+
+	return &Memory{logger: logger, conf: conf, deliveries: make(chan delivery)}
+}
+
+// Publish передаёт сообщение получателю и ждёт, пока тот обработает его или исчерпает попытки.
+func (q *Memory) Publish(ctx context.Context, msg domain.OutboxMessage) error {
+	d := delivery{msg: msg, done: make(chan struct{})}
+
+	select {
+	case q.deliveries <- d:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume передаёт сообщения handle по одному до отмены ctx.
+func (q *Memory) Consume(ctx context.Context, handle Handler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-q.deliveries:
+			if q.process(ctx, d.msg, handle) {
+				close(d.done)
+			}
+		}
+	}
+}
+
+// process повторяет handle с растущей паузой, пока тот не вернёт nil. Сообщение, которое
+// не удалось обработать за MaxAttempts попыток, логируется и отбрасывается, чтобы
+// не задерживать остальные. false означает, что обработку прервала отмена ctx:
+// сообщение остаётся неподтверждённым.
+func (q *Memory) process(ctx context.Context, msg domain.OutboxMessage, handle Handler) bool {
+	backoff := q.conf.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := handle(ctx, msg)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		if attempt == q.conf.MaxAttempts {
+			q.logger.Error(fmt.Sprintf("failed to handle %s message %d, giving up after %d attempts: %v",
+				msg.Topic, msg.ID, attempt, err))
+			return true
+		}
+		q.logger.Warn(fmt.Sprintf("failed to handle %s message %d, attempt %d/%d: %v",
+			msg.Topic, msg.ID, attempt, q.conf.MaxAttempts, err))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return false
+		}
+
+		backoff *= 2
+		if backoff > q.conf.MaxBackoff {
+			backoff = q.conf.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errSend = errors.New("send failed")
+
+type nopLogger struct{}
+
+func (nopLogger) Warn(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+
+func newTestQueue(maxAttempts int) *Memory {
+	return NewMemory(nopLogger{}, config.OutboxConf{
+		MaxAttempts: maxAttempts, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+	})
+}
+
+func TestMemory_PublishWaitsForAck(t *testing.T) {
+	q := newTestQueue(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Без получателя сообщение не подтверждено, Publish ждёт до истечения ctx.
+	timeoutCtx, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	assert.ErrorIs(t, q.Publish(timeoutCtx, domain.OutboxMessage{ID: 1}), context.DeadlineExceeded)
+
+	handled := make(chan int, 2)
+	go q.Consume(ctx, func(_ context.Context, msg domain.OutboxMessage) error {
+		handled <- msg.ID
+		return nil
+	})
+
+	require.NoError(t, q.Publish(ctx, domain.OutboxMessage{ID: 2}))
+	assert.Equal(t, 2, <-handled)
+}
+
+func TestMemory_Redelivery(t *testing.T) {
+	q := newTestQueue(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	go q.Consume(ctx, func(_ context.Context, msg domain.OutboxMessage) error {
+		// Сообщение 1 обрабатывается с третьей попытки, сообщение 2 не обрабатывается никогда.
+		if n := calls.Add(1); msg.ID == 2 || n < 3 {
+			return errSend
+		}
+		return nil
+	})
+
+	require.NoError(t, q.Publish(ctx, domain.OutboxMessage{ID: 1}))
+	assert.EqualValues(t, 3, calls.Load())
+
+	// После MaxAttempts сообщение отбрасывается и не задерживает очередь.
+	calls.Store(0)
+	require.NoError(t, q.Publish(ctx, domain.OutboxMessage{ID: 2}))
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestMemory_StopBeforeAck(t *testing.T) {
+	q := newTestQueue(5)
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+
+	go q.Consume(consumerCtx, func(context.Context, domain.OutboxMessage) error {
+		stopConsumer()
+		return errSend
+	})
+
+	// Получатель остановился, не обработав сообщение: подтверждения нет.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Publish(ctx, domain.OutboxMessage{ID: 1}), context.DeadlineExceeded)
+}
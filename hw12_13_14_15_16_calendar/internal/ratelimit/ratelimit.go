@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit задаёт token bucket: Rate токенов в секунду и ёмкость Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed bool
+	// Remaining — сколько запросов ещё можно сделать без ожидания.
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен, если запрос отклонён.
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние корзин. Реализация в памяти подходит для одного экземпляра
+// сервиса, для нескольких можно подключить общее хранилище (например, Redis).
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	idleTTL time.Duration
+	sweepAt time.Time
+}
+
+// NewMemoryStore создаёт хранилище в памяти; корзины, не использованные дольше idleTTL, удаляются.
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	if idleTTL <= 0 {
+		idleTTL = 10 * time.Minute
+	}
+	return &MemoryStore{buckets: make(map[string]*bucket), idleTTL: idleTTL}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+
+	retryAfter := time.Duration(math.MaxInt64)
+	if limit.Rate > 0 {
+		retryAfter = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	return Result{Allowed: false, RetryAfter: retryAfter}, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.sweepAt) {
+		return
+	}
+	s.sweepAt = now.Add(s.idleTTL)
+
+	for key, b := range s.buckets {
+		if now.Sub(b.lastSeen) > s.idleTTL {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	limit := Limit{Rate: 2, Burst: 3}
+	now := time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, err := store.Allow(ctx, "user:1", limit, now)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, err := store.Allow(ctx, "user:1", limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// Другой ключ расходует свою корзину.
+	res, err = store.Allow(ctx, "user:2", limit, now)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// За полсекунды накапливается один токен.
+	res, err = store.Allow(ctx, "user:1", limit, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_SweepsIdleBuckets(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Now()
+	ctx := context.Background()
+
+	_, _ = store.Allow(ctx, "a", limit, now)
+	_, _ = store.Allow(ctx, "b", limit, now.Add(2*time.Minute))
+
+	assert.Len(t, store.buckets, 1)
+}
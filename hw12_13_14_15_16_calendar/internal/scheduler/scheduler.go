@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/scheduler")
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+type Application interface {
+	ListDueReminders(ctx context.Context, now time.Time) ([]domain.Reminder, error)
+	SendReminder(ctx context.Context, reminder domain.Reminder) error
+}
+
+type Metrics interface {
+	ObserveReminder(err error)
+}
+
+type Scheduler struct {
+	logger   Logger
+	app      Application
+	metrics  Metrics
+	interval atomic.Int64
+	reset    chan struct{}
+}
+
+func New(logger Logger, app Application, metrics Metrics, conf config.SchedulerConf) *Scheduler {
+	if conf.Interval <= 0 {
+		conf.Interval = time.Minute
+	}
+
+	s := &Scheduler{logger: logger, app: app, metrics: metrics, reset: make(chan struct{}, 1)}
+	s.interval.Store(int64(conf.Interval))
+	return s
+}
+
+// SetInterval меняет период опроса на лету, новый период отсчитывается с момента вызова.
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if time.Duration(s.interval.Swap(int64(interval))) == interval {
+		return
+	}
+
+	select {
+	case s.reset <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) Interval() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// Run периодически отправляет наступившие напоминания до отмены ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info(fmt.Sprintf("scheduler started with interval %s", s.Interval()))
+
+	timer := time.NewTimer(s.Interval())
+	defer timer.Stop()
+
+	s.Tick(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("scheduler stopped")
+			return
+		case <-s.reset:
+			s.logger.Info(fmt.Sprintf("scheduler interval changed to %s", s.Interval()))
+			timer.Reset(s.Interval())
+		case <-timer.C:
+			s.Tick(ctx, time.Now())
+			timer.Reset(s.Interval())
+		}
+	}
+}
+
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	ctx, span := tracer.Start(ctx, "Scheduler.Tick")
+	defer span.End()
+
+	reminders, err := s.app.ListDueReminders(ctx, now)
+	if err != nil {
+		span.RecordError(err)
+		s.logger.Error(fmt.Sprintf("failed to list due reminders: %v", err))
+		return
+	}
+	span.SetAttributes(attribute.Int("reminders.due", len(reminders)))
+
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			return
+		}
+		err := s.app.SendReminder(ctx, reminder)
+		s.metrics.ObserveReminder(err)
+		switch {
+		case errors.Is(err, domain.ErrReminderAlreadySent):
+			// Напоминание успел отправить другой экземпляр планировщика — штатная гонка.
+			s.logger.Info(fmt.Sprintf("reminder %d for event %d already sent, skipped",
+				reminder.ID, reminder.EventID))
+		case err != nil:
+			s.logger.Error(fmt.Sprintf("failed to send reminder %d for event %d: %v",
+				reminder.ID, reminder.EventID, err))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStorage = errors.New("storage unavailable")
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	infos  []string
+	errors []string
+}
+
+func (l *recordingLogger) Info(args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.infos = append(l.infos, fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Error(args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fmt.Sprint(args...))
+}
+
+// fakeApp отдаёт due на каждый опрос; sendErrs задаёт ошибку отправки по ID напоминания.
+// О каждом опросе сообщается в ticks, если он задан.
+type fakeApp struct {
+	mu       sync.Mutex
+	due      []domain.Reminder
+	listErr  error
+	sendErrs map[int]error
+	sent     []int
+	ticks    chan time.Time
+}
+
+func (a *fakeApp) ListDueReminders(_ context.Context, now time.Time) ([]domain.Reminder, error) {
+	if a.ticks != nil {
+		a.ticks <- now
+	}
+	return a.due, a.listErr
+}
+
+func (a *fakeApp) SendReminder(_ context.Context, reminder domain.Reminder) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.sendErrs[reminder.ID]; err != nil {
+		return err
+	}
+	a.sent = append(a.sent, reminder.ID)
+	return nil
+}
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	results []error
+}
+
+func (m *recordingMetrics) ObserveReminder(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, err)
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	logger := &recordingLogger{}
+	metrics := &recordingMetrics{}
+	app := &fakeApp{
+		due: []domain.Reminder{{ID: 1, EventID: 10}, {ID: 2, EventID: 20}, {ID: 3, EventID: 30}},
+		sendErrs: map[int]error{
+			2: domain.ErrReminderAlreadySent,
+			3: errStorage,
+		},
+	}
+
+	New(logger, app, metrics, config.SchedulerConf{}).Tick(context.Background(), time.Now())
+
+	assert.Equal(t, []int{1}, app.sent)
+	assert.Equal(t, []error{nil, domain.ErrReminderAlreadySent, errStorage}, metrics.results)
+
+	// Напоминание, отправленное другим экземпляром, — не ошибка; остальные сбои не мешают следующим.
+	require.Len(t, logger.errors, 1)
+	assert.Contains(t, logger.errors[0], "reminder 3 for event 30")
+	assert.Contains(t, logger.infos, "reminder 2 for event 20 already sent, skipped")
+}
+
+func TestScheduler_TickListError(t *testing.T) {
+	logger := &recordingLogger{}
+	metrics := &recordingMetrics{}
+	app := &fakeApp{due: []domain.Reminder{{ID: 1}}, listErr: errStorage}
+
+	New(logger, app, metrics, config.SchedulerConf{}).Tick(context.Background(), time.Now())
+
+	assert.Empty(t, app.sent)
+	assert.Empty(t, metrics.results)
+	require.Len(t, logger.errors, 1)
+	assert.Contains(t, logger.errors[0], errStorage.Error())
+}
+
+func TestScheduler_TickStopsOnCancel(t *testing.T) {
+	app := &fakeApp{due: []domain.Reminder{{ID: 1}, {ID: 2}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	New(&recordingLogger{}, app, &recordingMetrics{}, config.SchedulerConf{}).Tick(ctx, time.Now())
+
+	assert.Empty(t, app.sent)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	app := &fakeApp{ticks: make(chan time.Time)}
+	s := New(&recordingLogger{}, app, &recordingMetrics{}, config.SchedulerConf{Interval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	// Первый опрос — сразу при запуске.
+	select {
+	case <-app.ticks:
+	case <-time.After(time.Second):
+		t.Fatal("no tick on start")
+	}
+
+	// Следующего опроса с часовым периодом не будет, пока период не уменьшат.
+	select {
+	case <-app.ticks:
+		t.Fatal("unexpected tick before the interval elapsed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.SetInterval(10 * time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, s.Interval())
+	for i := 0; i < 2; i++ {
+		select {
+		case <-app.ticks:
+		case <-time.After(time.Second):
+			t.Fatal("no tick after SetInterval")
+		}
+	}
+
+	cancel()
+	// Опрос мог начаться до отмены: его нужно дочитать, чтобы Run завершился.
+	for stopped := false; !stopped; {
+		select {
+		case <-app.ticks:
+		case <-done:
+			stopped = true
+		case <-time.After(time.Second):
+			t.Fatal("Run did not stop after cancel")
+		}
+	}
+}
+
+func TestScheduler_SetInterval(t *testing.T) {
+	s := New(&recordingLogger{}, &fakeApp{}, &recordingMetrics{}, config.SchedulerConf{})
+	assert.Equal(t, time.Minute, s.Interval())
+
+	s.SetInterval(5 * time.Second)
+	assert.Equal(t, 5*time.Second, s.Interval())
+	assert.Len(t, s.reset, 1)
+
+	// Тот же период не сбрасывает таймер, недопустимый заменяется значением по умолчанию.
+	<-s.reset
+	s.SetInterval(5 * time.Second)
+	assert.Empty(t, s.reset)
+	s.SetInterval(0)
+	assert.Equal(t, time.Minute, s.Interval())
+}
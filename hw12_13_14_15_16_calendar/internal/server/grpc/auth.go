@@ -0,0 +1,90 @@
+package internalgrpc
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyMetadata = "x-api-key"
+	userIDMetadata = "x-user-id"
+)
+
+type Authenticator interface {
+	Enabled() bool
+	Required(route string) bool
+	Authenticate(ctx context.Context, creds auth.Credentials) (int, error)
+}
+
+// authenticate кладёт пользователя в контекст или возвращает Unauthenticated.
+// При выключенной аутентификации пользователь берётся из метаданных x-user-id.
+func authenticate(ctx context.Context, authenticator Authenticator, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if !authenticator.Enabled() {
+		if userID, err := strconv.Atoi(metadataCarrier(md).Get(userIDMetadata)); err == nil && userID > 0 {
+			return withUser(ctx, userID), nil
+		}
+		return ctx, nil
+	}
+	if !authenticator.Required(method) {
+		return ctx, nil
+	}
+
+	creds := auth.Credentials{
+		BearerToken: auth.ParseAuthorization(metadataCarrier(md).Get("authorization")),
+		APIKey:      metadataCarrier(md).Get(apiKeyMetadata),
+	}
+
+	userID, err := authenticator.Authenticate(ctx, creds)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return withUser(ctx, userID), nil
+}
+
+func withUser(ctx context.Context, userID int) context.Context {
+	return logger.WithField(auth.WithUserID(ctx, userID), logger.FieldUserID, userID)
+}
+
+func authUnaryInterceptor(authenticator Authenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(authenticator Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream подменяет контекст потока, чтобы обработчик видел данные, добавленные перехватчиками.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,103 @@
+package internalgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var testAuthConf = config.AuthConf{
+	Enabled: true,
+	JWT: config.JWTConf{
+		Keys: []config.JWTKeyConf{{ID: "main", Algorithm: "HS256", Secret: "secret"}},
+	},
+	APIKeys:      []config.APIKeyConf{{Key: "key-2", UserID: 2}},
+	PublicRoutes: []string{"/grpc.health.v1.Health/Check"},
+}
+
+func withMetadata(pairs ...string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func bearer(t *testing.T, subject, secret string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestAuth_Unauthenticated(t *testing.T) {
+	conn := startServer(t, &recordingMetrics{}, testAuthConf)
+	client := pb.NewEventServiceClient(conn)
+
+	for name, ctx := range map[string]context.Context{
+		"missing":      context.Background(),
+		"malformed":    withMetadata("authorization", "Bearer not-a-jwt"),
+		"wrong secret": withMetadata("authorization", bearer(t, "1", "other")),
+		"unknown key":  withMetadata(apiKeyMetadata, "key-3"),
+		"user id":      withMetadata(userIDMetadata, "1"),
+	} {
+		_, err := client.ListTags(ctx, &pb.ListTagsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+	}
+
+	// Поток проверяется так же: Watch отклоняется раньше, чем дойдёт до Unimplemented.
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_PublicMethod(t *testing.T) {
+	conn := startServer(t, &recordingMetrics{}, testAuthConf)
+
+	_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+func TestAuth_OtherUsersEvent(t *testing.T) {
+	client := pb.NewEventServiceClient(startServer(t, &recordingMetrics{}, testAuthConf))
+	owner := withMetadata("authorization", bearer(t, "1", "secret"))
+	other := withMetadata(apiKeyMetadata, "key-2")
+
+	created, err := client.CreateEvent(owner, &pb.CreateEventRequest{Event: &pb.Event{
+		Title:     "Standup",
+		EventTime: timestamppb.New(time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)),
+		Duration:  "PT15M",
+	}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, created.GetUserId())
+
+	_, err = client.GetEvent(other, &pb.GetEventRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.DeleteEvent(other, &pb.DeleteEventRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetEvent(owner, &pb.GetEventRequest{Id: created.GetId()})
+	assert.NoError(t, err)
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	client := pb.NewEventServiceClient(startServer(t, &recordingMetrics{}, config.AuthConf{}))
+
+	_, err := client.ListTags(withMetadata(userIDMetadata, "1"), &pb.ListTagsRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.ListTags(context.Background(), &pb.ListTagsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
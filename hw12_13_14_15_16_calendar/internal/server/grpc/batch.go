@@ -0,0 +1,187 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *EventService) BatchCreateEvents(
+	ctx context.Context, req *pb.BatchCreateEventsRequest,
+) (*pb.BatchEventsResponse, error) {
+	userID, mode, err := batchRequest(ctx, len(req.GetEvents()), req.GetMode())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, replayed, err := idempotent(ctx, s, userID, "BatchCreateEvents", req,
+		func(ctx context.Context) (*pb.BatchEventsResponse, error) {
+			events, indexes, errs := decodeBatch(req.GetEvents(), userID, mode)
+			if len(events) > 0 {
+				appErrs, err := s.app.CreateEvents(ctx, events, mode)
+				if err != nil {
+					return nil, s.toStatus(ctx, err)
+				}
+				mergeBatchErrors(errs, indexes, appErrs)
+			}
+
+			return s.batchResponse(ctx, errs, indexes, func(i int, result *pb.BatchResult) {
+				result.Event = eventToProto(events[i])
+				result.Id = result.GetEvent().GetId()
+			}), nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if replayed {
+		setBatchHTTPCode(ctx, resp)
+	}
+	return resp, nil
+}
+
+func (s *EventService) BatchUpdateEvents(
+	ctx context.Context, req *pb.BatchUpdateEventsRequest,
+) (*pb.BatchEventsResponse, error) {
+	userID, mode, err := batchRequest(ctx, len(req.GetEvents()), req.GetMode())
+	if err != nil {
+		return nil, err
+	}
+
+	events, indexes, errs := decodeBatch(req.GetEvents(), userID, mode)
+	for i, idx := range indexes {
+		events[i].ID = int(req.GetEvents()[idx].GetId())
+	}
+	if len(events) > 0 {
+		appErrs, err := s.app.UpdateEvents(ctx, userID, events, mode)
+		if err != nil {
+			return nil, s.toStatus(ctx, err)
+		}
+		mergeBatchErrors(errs, indexes, appErrs)
+	}
+
+	resp := s.batchResponse(ctx, errs, indexes, func(i int, result *pb.BatchResult) {
+		result.Event = eventToProto(events[i])
+	})
+	for i, result := range resp.GetResults() {
+		result.Id = req.GetEvents()[i].GetId()
+	}
+	return resp, nil
+}
+
+func (s *EventService) BatchDeleteEvents(
+	ctx context.Context, req *pb.BatchDeleteEventsRequest,
+) (*pb.BatchEventsResponse, error) {
+	userID, mode, err := batchRequest(ctx, len(req.GetIds()), req.GetMode())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		ids[i] = int(id)
+	}
+
+	errs, err := s.app.DeleteEvents(ctx, userID, ids, mode)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := s.batchResponse(ctx, errs, nil, nil)
+	for i, result := range resp.GetResults() {
+		result.Id = req.GetIds()[i]
+	}
+	return resp, nil
+}
+
+func batchRequest(ctx context.Context, size int, rawMode string) (int, domain.BatchMode, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	mode, err := domain.ParseBatchMode(rawMode)
+	if err != nil {
+		return 0, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := domain.ValidateBatchSize(size); err != nil {
+		return 0, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return userID, mode, nil
+}
+
+// decodeBatch разбирает события пакета. Ошибка разбора — такая же ошибка элемента,
+// как ошибка валидации: в режиме «всё или ничего» пакет отменяется до обращения
+// к приложению, но остальные события всё равно проверяются, чтобы вернуть все ошибки сразу.
+func decodeBatch(
+	items []*pb.Event, userID int, mode domain.BatchMode,
+) (events []domain.Event, indexes []int, errs []error) {
+	errs = make([]error, len(items))
+	for i, item := range items {
+		event, err := eventFromProto(item)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		event.UserID = userID
+		events = append(events, event)
+		indexes = append(indexes, i)
+	}
+
+	if mode == domain.BatchAllOrNothing && domain.BatchFailed(errs) {
+		for i, idx := range indexes {
+			errs[idx] = events[i].Validate()
+		}
+		domain.AbortBatch(errs)
+		return nil, nil, errs
+	}
+	return events, indexes, errs
+}
+
+// mergeBatchErrors раскладывает ошибки приложения по позициям исходного запроса.
+func mergeBatchErrors(errs []error, indexes []int, appErrs []error) {
+	for i, idx := range indexes {
+		errs[idx] = appErrs[i]
+	}
+}
+
+// batchResponse собирает результаты в порядке запроса; fill дополняет успешный результат
+// по его позиции indexes в списке, переданном приложению.
+func (s *EventService) batchResponse(
+	ctx context.Context, errs []error, indexes []int, fill func(i int, result *pb.BatchResult),
+) *pb.BatchEventsResponse {
+	resp := &pb.BatchEventsResponse{Results: make([]*pb.BatchResult, len(errs))}
+	for i := range errs {
+		resp.Results[i] = &pb.BatchResult{Index: int32(i)} //nolint:gosec
+	}
+
+	for i, idx := range indexes {
+		if errs[idx] == nil {
+			fill(i, resp.Results[idx])
+		}
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			resp.Succeeded++
+			continue
+		}
+		st := status.Convert(s.toStatus(ctx, err))
+		resp.Results[i].Code = st.Code().String()
+		resp.Results[i].Error = st.Message()
+		resp.Failed++
+	}
+
+	setBatchHTTPCode(ctx, resp)
+	return resp
+}
+
+// setBatchHTTPCode: если не применён ни один элемент, шлюз отдаёт ответ с кодом 422.
+func setBatchHTTPCode(ctx context.Context, resp *pb.BatchEventsResponse) {
+	if resp.GetSucceeded() == 0 && resp.GetFailed() > 0 {
+		setHTTPCode(ctx, 422)
+	}
+}
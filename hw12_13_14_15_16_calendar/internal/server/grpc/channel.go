@@ -0,0 +1,102 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type ChannelApplication interface {
+	GetNotificationChannel(ctx context.Context, userID int) (domain.NotificationChannel, error)
+	SetNotificationChannel(ctx context.Context, channel *domain.NotificationChannel) error
+	ResetNotificationChannel(ctx context.Context, userID int) error
+}
+
+var errChannelRequired = errors.New("channel is required")
+
+// ChannelService реализует API канала уведомлений; через шлюз он же обслуживает HTTP.
+type ChannelService struct {
+	pb.UnimplementedNotificationChannelServiceServer
+	logger Logger
+	app    ChannelApplication
+}
+
+func NewChannelService(logger Logger, app ChannelApplication) *ChannelService {
+	return &ChannelService{logger: logger, app: app}
+}
+
+func (s *ChannelService) GetNotificationChannel(
+	ctx context.Context, _ *pb.GetNotificationChannelRequest,
+) (*pb.NotificationChannel, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := s.app.GetNotificationChannel(ctx, userID)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return channelToProto(channel), nil
+}
+
+func (s *ChannelService) SetNotificationChannel(
+	ctx context.Context, req *pb.SetNotificationChannelRequest,
+) (*pb.NotificationChannel, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetChannel() == nil {
+		return nil, status.Error(codes.InvalidArgument, errChannelRequired.Error())
+	}
+
+	channel := domain.NotificationChannel{
+		UserID:  userID,
+		Type:    domain.ChannelType(req.GetChannel().GetType()),
+		Address: req.GetChannel().GetAddress(),
+	}
+	if err := s.app.SetNotificationChannel(ctx, &channel); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return channelToProto(channel), nil
+}
+
+func (s *ChannelService) ResetNotificationChannel(
+	ctx context.Context, _ *pb.ResetNotificationChannelRequest,
+) (*emptypb.Empty, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.ResetNotificationChannel(ctx, userID); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	setHTTPCode(ctx, 204)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ChannelService) toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUnknownChannelType), errors.Is(err, domain.ErrInvalidChannelAddress):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		s.logger.ErrorContext(ctx, "notification channel request failed: "+err.Error())
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func channelToProto(channel domain.NotificationChannel) *pb.NotificationChannel {
+	return &pb.NotificationChannel{
+		UserId:  int32(channel.UserID), //nolint:gosec
+		Type:    string(channel.Type),
+		Address: channel.Address,
+	}
+}
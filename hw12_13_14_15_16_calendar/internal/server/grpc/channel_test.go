@@ -0,0 +1,40 @@
+package internalgrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateway_NotificationChannel(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/notification-channel", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		withUserHandler(1, gateway).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"userId":1,"type":"log","address":""}`, rec.Body.String())
+
+	rec = do(http.MethodPut, `{"type":"email","address":"not an address"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPut, `{"type":"email","address":"user@example.com"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"userId":1,"type":"email","address":"user@example.com"}`, rec.Body.String())
+
+	rec = do(http.MethodDelete, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = do(http.MethodGet, "")
+	assert.JSONEq(t, `{"userId":1,"type":"log","address":""}`, rec.Body.String())
+}
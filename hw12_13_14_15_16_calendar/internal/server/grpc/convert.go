@@ -0,0 +1,93 @@
+package internalgrpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var errEventRequired = errors.New("event is required")
+
+func eventToProto(e domain.Event) *pb.Event {
+	event := &pb.Event{
+		Id:           int32(e.ID), //nolint:gosec
+		Title:        e.Title,
+		EventTime:    timestamppb.New(e.EventTime),
+		Duration:     domain.FormatISODuration(e.Duration),
+		Description:  e.Description,
+		UserId:       int32(e.UserID), //nolint:gosec
+		TimeToNotify: optionalTimestamp(e.TimeToNotify),
+		Reminders:    make([]*pb.Reminder, len(e.Reminders)),
+		Category:     e.Category,
+		Color:        e.Color,
+		Tags:         e.Tags,
+		AllDay:       e.AllDay,
+	}
+	if e.AllDay {
+		first, last := e.Dates()
+		event.StartDate, event.EndDate = first.Format(domain.DateLayout), last.Format(domain.DateLayout)
+	}
+	for i, r := range e.Reminders {
+		event.Reminders[i] = &pb.Reminder{
+			Id:      int32(r.ID),      //nolint:gosec
+			EventId: int32(r.EventID), //nolint:gosec
+			Offset:  domain.FormatISODuration(r.Offset),
+			FireAt:  optionalTimestamp(r.FireAt),
+			Status:  string(r.Status),
+			SentAt:  optionalTimestamp(r.SentAt),
+		}
+	}
+	return event
+}
+
+// eventFromProto берёт из запроса только поля, которые задаёт клиент.
+func eventFromProto(e *pb.Event) (domain.Event, error) {
+	if e == nil {
+		return domain.Event{}, errEventRequired
+	}
+
+	event := domain.Event{
+		Title:       e.GetTitle(),
+		Description: e.GetDescription(),
+		Category:    e.GetCategory(),
+		Color:       e.GetColor(),
+		Tags:        e.GetTags(),
+		AllDay:      e.GetAllDay(),
+	}
+	if e.GetEventTime() != nil {
+		event.EventTime = e.GetEventTime().AsTime()
+	}
+
+	if e.GetDuration() != "" {
+		duration, err := domain.ParseISODuration(e.GetDuration())
+		if err != nil {
+			return domain.Event{}, err
+		}
+		event.Duration = duration
+	}
+
+	if e.GetAllDay() && e.GetStartDate() != "" {
+		if err := event.SetDateStrings(e.GetStartDate(), e.GetEndDate()); err != nil {
+			return domain.Event{}, err
+		}
+	}
+
+	for _, r := range e.GetReminders() {
+		offset, err := domain.ParseISODuration(r.GetOffset())
+		if err != nil {
+			return domain.Event{}, err
+		}
+		event.Reminders = append(event.Reminders, domain.Reminder{Offset: offset})
+	}
+	return event, nil
+}
+
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,280 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const dateLayout = "2006-01-02"
+
+type Application interface {
+	CreateEvent(ctx context.Context, event *domain.Event) error
+	GetEvent(ctx context.Context, id int) (domain.Event, error)
+	GetEventForUpdate(ctx context.Context, id int) (domain.Event, error)
+	UpdateEvent(ctx context.Context, id int, event *domain.Event) error
+	DeleteEvent(ctx context.Context, id int) error
+	ListByDay(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByWeek(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByMonth(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	SearchEvents(ctx context.Context, q domain.SearchQuery) ([]domain.SearchResult, error)
+	TagCounts(ctx context.Context, userID int) ([]domain.TagCount, error)
+	CreateEvents(ctx context.Context, events []domain.Event, mode domain.BatchMode) ([]error, error)
+	UpdateEvents(ctx context.Context, userID int, events []domain.Event, mode domain.BatchMode) ([]error, error)
+	DeleteEvents(ctx context.Context, userID int, ids []int, mode domain.BatchMode) ([]error, error)
+}
+
+// EventService реализует API событий; через шлюз он же обслуживает HTTP.
+type EventService struct {
+	pb.UnimplementedEventServiceServer
+	logger      Logger
+	app         Application
+	idempotency Idempotency
+}
+
+// NewEventService создаёт сервис; при idempotency == nil заголовок Idempotency-Key игнорируется.
+func NewEventService(logger Logger, app Application, idempotency Idempotency) *EventService {
+	return &EventService{logger: logger, app: app, idempotency: idempotency}
+}
+
+func (s *EventService) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.Event, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, _, err := idempotent(ctx, s, userID, "CreateEvent", req, func(ctx context.Context) (*pb.Event, error) {
+		event, err := eventFromProto(req.GetEvent())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		event.UserID = userID
+		if err := s.app.CreateEvent(ctx, &event); err != nil {
+			return nil, s.toStatus(ctx, err)
+		}
+		return eventToProto(event), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setHTTPCode(ctx, 201)
+	return resp, nil
+}
+
+func (s *EventService) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.Event, error) {
+	event, err := s.getUserEvent(ctx, int(req.GetId()), s.app.GetEvent)
+	if err != nil {
+		return nil, err
+	}
+	return eventToProto(event), nil
+}
+
+func (s *EventService) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.Event, error) {
+	current, err := s.getUserEvent(ctx, int(req.GetId()), s.app.GetEventForUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := eventFromProto(req.GetEvent())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	event.UserID = current.UserID
+	if err := s.app.UpdateEvent(ctx, current.ID, &event); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return eventToProto(event), nil
+}
+
+func (s *EventService) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*emptypb.Empty, error) {
+	event, err := s.getUserEvent(ctx, int(req.GetId()), s.app.GetEventForUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.DeleteEvent(ctx, event.ID); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	setHTTPCode(ctx, 204)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *EventService) ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(dateLayout, req.GetDate())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "date must be in YYYY-MM-DD format")
+	}
+
+	tags, err := domain.NewTagFilter(req.GetTags(), req.GetTagMatch())
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	var events []domain.Event
+	switch req.GetPeriod() {
+	case "", "day":
+		events, err = s.app.ListByDay(ctx, userID, date, tags)
+	case "week":
+		events, err = s.app.ListByWeek(ctx, userID, date, tags)
+	case "month":
+		events, err = s.app.ListByMonth(ctx, userID, date, tags)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "period must be one of day, week, month")
+	}
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &pb.ListEventsResponse{Events: make([]*pb.Event, len(events))}
+	for i, event := range events {
+		resp.Events[i] = eventToProto(event)
+	}
+	return resp, nil
+}
+
+func (s *EventService) SearchEvents(
+	ctx context.Context, req *pb.SearchEventsRequest,
+) (*pb.SearchEventsResponse, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := domain.NewTagFilter(req.GetTags(), req.GetTagMatch())
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	q := domain.SearchQuery{Text: req.GetQuery(), UserID: userID, Tags: tags, Limit: int(req.GetLimit())}
+	if req.GetFrom() != nil {
+		q.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		q.To = req.GetTo().AsTime()
+	}
+
+	results, err := s.app.SearchEvents(ctx, q)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &pb.SearchEventsResponse{Hits: make([]*pb.SearchHit, len(results))}
+	for i, result := range results {
+		resp.Hits[i] = &pb.SearchHit{
+			Event:   eventToProto(result.Event),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		}
+	}
+	return resp, nil
+}
+
+func (s *EventService) ListTags(ctx context.Context, _ *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.app.TagCounts(ctx, userID)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &pb.ListTagsResponse{Tags: make([]*pb.TagCount, len(counts))}
+	for i, c := range counts {
+		resp.Tags[i] = &pb.TagCount{Tag: c.Tag, Count: int32(c.Count)} //nolint:gosec
+	}
+	return resp, nil
+}
+
+// getUserEvent скрывает чужие события так же, как несуществующие. Перед изменением
+// событие читается через GetEventForUpdate, иначе только что созданное событие
+// могло бы не найтись на реплике.
+func (s *EventService) getUserEvent(
+	ctx context.Context, id int, get func(ctx context.Context, id int) (domain.Event, error),
+) (domain.Event, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	event, err := get(ctx, id)
+	if err == nil && event.UserID != userID {
+		err = domain.ErrEventNotFound
+	}
+	if err != nil {
+		return domain.Event{}, s.toStatus(ctx, err)
+	}
+	return event, nil
+}
+
+// toStatus переводит ошибки домена в коды gRPC; шлюз затем переводит их в статусы HTTP.
+func (s *EventService) toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrEmptyTitle),
+		errors.Is(err, domain.ErrInvalidEventTime),
+		errors.Is(err, domain.ErrInvalidDuration),
+		errors.Is(err, domain.ErrInvalidAllDayDuration),
+		errors.Is(err, domain.ErrInvalidDate),
+		errors.Is(err, domain.ErrInvalidDateRange),
+		errors.Is(err, domain.ErrInvalidReminderOffset),
+		errors.Is(err, domain.ErrInvalidISODuration),
+		errors.Is(err, domain.ErrInvalidTag),
+		errors.Is(err, domain.ErrTooManyTags),
+		errors.Is(err, domain.ErrInvalidCategory),
+		errors.Is(err, domain.ErrInvalidColor),
+		errors.Is(err, domain.ErrInvalidTagMatch),
+		errors.Is(err, domain.ErrInvalidBatchMode),
+		errors.Is(err, domain.ErrEmptySearchQuery),
+		errors.Is(err, domain.ErrInvalidSearchRange),
+		errors.Is(err, domain.ErrInvalidSearchLimit),
+		errors.Is(err, domain.ErrEmptyBatch),
+		errors.Is(err, domain.ErrBatchTooLarge),
+		errors.Is(err, domain.ErrInvalidIdempotencyKey),
+		errors.Is(err, errEventRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrBatchAborted),
+		errors.Is(err, domain.ErrIdempotencyInProgress):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, domain.ErrIdempotencyKeyMismatch):
+		setHTTPCode(ctx, 422)
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		s.logger.ErrorContext(ctx, "event request failed: "+err.Error())
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func requestUser(ctx context.Context) (int, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "user is not identified")
+	}
+	return userID, nil
+}
+
+// setHTTPCode задаёт статус ответа шлюза, отличный от 200.
+func setHTTPCode(ctx context.Context, code int) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(httpCodeHeader, strconv.Itoa(code)))
+}
@@ -0,0 +1,359 @@
+package internalgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/idempotency"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})                          {}
+func (nopLogger) Error(...interface{})                         {}
+func (nopLogger) Debug(...interface{})                         {}
+func (nopLogger) Warn(...interface{})                          {}
+func (nopLogger) InfoContext(context.Context, ...interface{})  {}
+func (nopLogger) ErrorContext(context.Context, ...interface{}) {}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, domain.EventAction, domain.Event) {}
+
+// testServices — сервисы поверх общего приложения с хранилищем в памяти.
+type testServices struct {
+	events   *EventService
+	webhooks *WebhookService
+	channels *ChannelService
+}
+
+func newTestServices() testServices {
+	storage := memorystorage.NewStorage()
+	calendar := app.New(nopLogger{}, storage, nopPublisher{}, nil)
+	guard := idempotency.New(nopLogger{}, storage, config.IdempotencyConf{TTL: time.Hour})
+	return testServices{
+		events:   NewEventService(nopLogger{}, calendar, guard),
+		webhooks: NewWebhookService(nopLogger{}, calendar),
+		channels: NewChannelService(nopLogger{}, calendar),
+	}
+}
+
+func newTestService() *EventService {
+	return newTestServices().events
+}
+
+func newTestGateway(t *testing.T) http.Handler {
+	t.Helper()
+
+	services := newTestServices()
+	gateway, err := NewGateway(context.Background(), services.events, services.webhooks, services.channels)
+	require.NoError(t, err)
+	return gateway
+}
+
+// withUserHandler имитирует пользователя, которого определил HTTP-сервер.
+func withUserHandler(userID int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+	})
+}
+
+func TestGateway_EventLifecycle(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(userID int, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		withUserHandler(userID, gateway).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(1, http.MethodPost, "/events",
+		`{"title":"Standup","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M","reminders":[{"offset":"PT5M"}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Grpc-Metadata-X-Http-Code"))
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "PT15M", created["duration"])
+	assert.Equal(t, "2025-11-05T10:00:00Z", created["eventTime"])
+	assert.EqualValues(t, 1, created["userId"])
+
+	rec = do(1, http.MethodGet, "/events?date=2025-11-05&period=week", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var events []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	assert.Len(t, events, 1)
+
+	rec = do(2, http.MethodGet, "/events?date=2025-11-05&period=week", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	// Чужое событие выглядит как несуществующее.
+	rec = do(2, http.MethodGet, "/events/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"event not found"}`, rec.Body.String())
+
+	rec = do(1, http.MethodGet, "/events:search?query=stand&from=2025-11-01T00:00:00Z", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var hits []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hits))
+	require.Len(t, hits, 1)
+	assert.Equal(t, "<mark>Standup</mark>", hits[0]["snippet"])
+
+	rec = do(2, http.MethodGet, "/events:search?query=standup", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	rec = do(1, http.MethodGet, "/events:search?query=%20-", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(1, http.MethodPut, "/events/1", `{"title":"","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(1, http.MethodDelete, "/events/1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+
+	rec = do(1, http.MethodGet, "/events/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGateway_Tags(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		withUserHandler(1, gateway).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/events:batchCreate", `{"events":[
+		{"title":"Night shift","eventTime":"2025-11-05T22:00:00Z","duration":"PT8H","tags":["On-Call"],"color":"#FF0000"},
+		{"title":"Sync","eventTime":"2025-11-05T10:00:00Z","duration":"PT30M","tags":["1:1","team"],"category":"work"}
+	]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"tags":["on-call"]`)
+	assert.Contains(t, rec.Body.String(), `"color":"#ff0000"`)
+
+	rec = do(http.MethodGet, "/events?date=2025-11-05&tags=on-call,team&tagMatch=any", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var events []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	assert.Len(t, events, 2)
+
+	rec = do(http.MethodGet, "/events?date=2025-11-05&tags=1:1&tags=team", "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, "work", events[0]["category"])
+
+	rec = do(http.MethodGet, "/tags", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"tag":"1:1","count":1},{"tag":"on-call","count":1},{"tag":"team","count":1}]`, rec.Body.String())
+
+	rec = do(http.MethodPost, "/events",
+		`{"title":"Bad","eventTime":"2025-11-05T10:00:00Z","duration":"PT1H","tags":["two words"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestEventService_ErrorCodes(t *testing.T) {
+	service := newTestService()
+
+	_, err := service.GetEvent(context.Background(), &pb.GetEventRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := auth.WithUserID(context.Background(), 1)
+	_, err = service.GetEvent(ctx, &pb.GetEventRequest{Id: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = service.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{Title: "x", Duration: "1h"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = service.ListEvents(ctx, &pb.ListEventsRequest{Date: "2025-11-05", Period: "year"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// laggingStorage пишет в основное хранилище, а без storage.WithPrimary читает события
+// из реплики, куда изменения ещё не дошли.
+type laggingStorage struct {
+	storage.Storage
+	replica storage.Storage
+}
+
+func (s *laggingStorage) Event() storage.EventRepository {
+	return &laggingEvents{EventRepository: s.Storage.Event(), replica: s.replica.Event()}
+}
+
+type laggingEvents struct {
+	storage.EventRepository
+	replica storage.EventRepository
+}
+
+func (r *laggingEvents) Get(ctx context.Context, id int) (domain.Event, error) {
+	if storage.PrimaryRequired(ctx) {
+		return r.EventRepository.Get(ctx, id)
+	}
+	return r.replica.Get(ctx, id)
+}
+
+func TestEventService_ChecksOwnershipOnPrimary(t *testing.T) {
+	lagging := &laggingStorage{Storage: memorystorage.NewStorage(), replica: memorystorage.NewStorage()}
+	service := NewEventService(nopLogger{}, app.New(nopLogger{}, lagging, nopPublisher{}, nil), nil)
+	ctx := auth.WithUserID(context.Background(), 1)
+
+	event := &pb.Event{Title: "Standup", EventTime: timestamppb.New(time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)),
+		Duration: "PT15M"}
+	created, err := service.CreateEvent(ctx, &pb.CreateEventRequest{Event: event})
+	require.NoError(t, err)
+
+	// Чтение идёт в реплику, которая ещё не знает о событии.
+	_, err = service.GetEvent(ctx, &pb.GetEventRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Изменение сразу после создания проверяет владельца по основной базе.
+	event.Title = "Daily"
+	updated, err := service.UpdateEvent(ctx, &pb.UpdateEventRequest{Id: created.GetId(), Event: event})
+	require.NoError(t, err)
+	assert.Equal(t, "Daily", updated.GetTitle())
+
+	_, err = service.DeleteEvent(ctx, &pb.DeleteEventRequest{Id: created.GetId()})
+	require.NoError(t, err)
+}
+
+func TestGateway_BatchModes(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(target, body string) (int, *pb.BatchEventsResponse) {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		withUserHandler(1, gateway).ServeHTTP(rec, req)
+
+		var resp pb.BatchEventsResponse
+		require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+		return rec.Code, &resp
+	}
+
+	events := `[
+		{"title":"Standup","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"},
+		{"title":"","eventTime":"2025-11-05T11:00:00Z","duration":"PT15M"},
+		{"title":"Retro","eventTime":"2025-11-05T12:00:00Z","duration":"1h"}
+	]`
+
+	code, resp := do("/events:batchCreate", `{"events":`+events+`}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.EqualValues(t, 3, resp.GetFailed())
+	assert.Equal(t, "Aborted", resp.GetResults()[0].GetCode())
+	assert.Equal(t, "InvalidArgument", resp.GetResults()[1].GetCode())
+	assert.Equal(t, "InvalidArgument", resp.GetResults()[2].GetCode())
+
+	code, resp = do("/events:batchCreate", `{"mode":"best_effort","events":`+events+`}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 1, resp.GetSucceeded())
+	assert.EqualValues(t, 1, resp.GetResults()[0].GetId())
+	assert.Equal(t, "Standup", resp.GetResults()[0].GetEvent().GetTitle())
+	assert.Empty(t, resp.GetResults()[0].GetCode())
+
+	code, resp = do("/events:batchUpdate",
+		`{"events":[{"id":1,"title":"Daily","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}]}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Daily", resp.GetResults()[0].GetEvent().GetTitle())
+
+	code, resp = do("/events:batchDelete", `{"ids":[1,2]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "Aborted", resp.GetResults()[0].GetCode())
+	assert.Equal(t, "NotFound", resp.GetResults()[1].GetCode())
+
+	code, resp = do("/events:batchDelete", `{"ids":[1,2],"mode":"best_effort"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 1, resp.GetSucceeded())
+
+	service := newTestService()
+	ctx := auth.WithUserID(context.Background(), 1)
+	_, err := service.BatchDeleteEvents(ctx, &pb.BatchDeleteEventsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = service.BatchDeleteEvents(ctx, &pb.BatchDeleteEventsRequest{Ids: []int32{1}, Mode: "partial"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGateway_IdempotencyKey(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(userID int, key, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		withUserHandler(userID, gateway).ServeHTTP(rec, req)
+		return rec
+	}
+	body := `{"title":"Standup","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`
+
+	first := do(1, "abc", "/events", body)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	replay := do(1, "abc", "/events", body)
+	require.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, first.Body.String(), replay.Body.String())
+
+	rec := do(1, "abc", "/events", `{"title":"Retro","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{"error":"idempotency key was already used with a different request"}`, rec.Body.String())
+
+	// Тот же ключ в другом методе — тоже другой запрос.
+	rec = do(1, "abc", "/events:batchCreate", `{"events":[`+body+`]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// Ключи у каждого пользователя свои.
+	rec = do(2, "abc", "/events", body)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+	// Ошибка не запоминается: после неё ключ можно использовать с исправленным запросом.
+	rec = do(1, "fix", "/events", `{"title":"","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(1, "fix", "/events", body)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	batch := `{"events":[{"title":"","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}]}`
+	rec = do(1, "batch", "/events:batchCreate", batch)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = do(1, "batch", "/events:batchCreate", batch)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+
+	rec = do(1, "", "/events", body)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var events []map[string]interface{}
+	list := httptest.NewRecorder()
+	withUserHandler(1, gateway).ServeHTTP(list, httptest.NewRequest(http.MethodGet, "/events?date=2025-11-05", nil))
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &events))
+	assert.Len(t, events, 3)
+}
@@ -0,0 +1,144 @@
+package internalgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// httpCodeHeader передаёт шлюзу статус HTTP, отличный от 200 (например, 201 при создании).
+const httpCodeHeader = "x-http-code"
+
+// NewGateway возвращает HTTP-обработчик, который транслирует REST-запросы в вызовы
+// сервисов по аннотациям из api/*.proto. Вызовы выполняются в том же процессе,
+// поэтому аутентификацию и прочие проверки делает HTTP-сервер.
+func NewGateway(
+	ctx context.Context,
+	events pb.EventServiceServer,
+	webhooks pb.WebhookServiceServer,
+	channels pb.NotificationChannelServiceServer,
+) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitDefaultValues: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: false},
+		}),
+		runtime.WithForwardResponseOption(forwardHTTPCode),
+		runtime.WithErrorHandler(writeGatewayError),
+		// Из заголовков запроса в метаданные попадает только ключ идемпотентности:
+		// пользователь уже в контексте.
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if key == "Idempotency-Key" {
+				return idempotencyKeyHeader, true
+			}
+			return "", false
+		}),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+
+	if err := pb.RegisterEventServiceHandlerServer(ctx, mux, events); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterWebhookServiceHandlerServer(ctx, mux, webhooks); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterNotificationChannelServiceHandlerServer(ctx, mux, channels); err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(&noContentWriter{ResponseWriter: w}, r)
+	}), nil
+}
+
+// noContentWriter отбрасывает тело и Content-Type ответа 204: шлюз сериализует
+// google.protobuf.Empty в "{}" независимо от статуса.
+type noContentWriter struct {
+	http.ResponseWriter
+	noBody bool
+}
+
+func (w *noContentWriter) WriteHeader(code int) {
+	if code == http.StatusNoContent {
+		w.noBody = true
+		w.Header().Del("Content-Type")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *noContentWriter) Write(b []byte) (int, error) {
+	if w.noBody {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *noContentWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func forwardHTTPCode(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	values := md.HeaderMD.Get(httpCodeHeader)
+	if len(values) == 0 {
+		return nil
+	}
+
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(code)
+	return nil
+}
+
+// outgoingHeader не выводит служебный код ответа, а отметку повтора отдаёт как Idempotent-Replayed.
+func outgoingHeader(key string) (string, bool) {
+	switch key {
+	case httpCodeHeader:
+		return "", false
+	case replayedHeader:
+		return "Idempotent-Replayed", true
+	default:
+		return runtime.MetadataHeaderPrefix + key, true
+	}
+}
+
+// writeGatewayError отвечает в том же формате {"error": ...}, что и остальной HTTP API.
+// Статус берётся из кода gRPC, если сервис не задал свой через setHTTPCode.
+func writeGatewayError(
+	ctx context.Context,
+	_ *runtime.ServeMux,
+	_ runtime.Marshaler,
+	w http.ResponseWriter,
+	_ *http.Request,
+	err error,
+) {
+	st := status.Convert(err)
+
+	code := runtime.HTTPStatusFromCode(st.Code())
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		if values := md.HeaderMD.Get(httpCodeHeader); len(values) > 0 {
+			if override, err := strconv.Atoi(values[0]); err == nil {
+				code = override
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: st.Message()})
+}
@@ -0,0 +1,52 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Пустое имя сервиса и ServiceName проверяют readiness, LivenessService — только liveness.
+const (
+	ServiceName     = "calendar"
+	LivenessService = "calendar.liveness"
+)
+
+type HealthChecker interface {
+	Live() health.Report
+	Ready(ctx context.Context) health.Report
+}
+
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	checker HealthChecker
+}
+
+func newHealthServer(checker HealthChecker) *healthServer {
+	return &healthServer{checker: checker}
+}
+
+func (h *healthServer) Check(
+	ctx context.Context,
+	req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+	var report health.Report
+
+	switch req.GetService() {
+	case "", ServiceName:
+		report = h.checker.Ready(ctx)
+	case LivenessService:
+		report = h.checker.Live()
+	default:
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	resp := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}
+	if !report.OK() {
+		resp.Status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// idempotencyKeyHeader — ключ идемпотентности в метаданных; шлюз берёт его из Idempotency-Key.
+	idempotencyKeyHeader = "idempotency-key"
+	// replayedHeader отмечает ответ, взятый из хранилища, а не полученный заново.
+	replayedHeader = "idempotent-replayed"
+)
+
+type Idempotency interface {
+	Do(
+		ctx context.Context, userID int, key string, request []byte, fn func(ctx context.Context) ([]byte, error),
+	) ([]byte, bool, error)
+}
+
+// idempotent выполняет fn один раз для ключа из метаданных запроса; без ключа просто вызывает fn.
+// С ключом fn получает контекст, ограниченный сроком блокировки ключа.
+// Хеш запроса считается по имени метода и детерминированной сериализации req. При повторе
+// ответ восстанавливается из хранилища, и replayed = true.
+func idempotent[T proto.Message](
+	ctx context.Context, s *EventService, userID int, method string, req proto.Message,
+	fn func(ctx context.Context) (T, error),
+) (resp T, replayed bool, err error) {
+	key := incomingIdempotencyKey(ctx)
+	if key == "" || s.idempotency == nil {
+		resp, err = fn(ctx)
+		return resp, false, err
+	}
+
+	request, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return resp, false, status.Error(codes.Internal, "internal error")
+	}
+
+	stored, replayed, err := s.idempotency.Do(ctx, userID, key, append([]byte(method+"\n"), request...),
+		func(ctx context.Context) ([]byte, error) {
+			if resp, err = fn(ctx); err != nil {
+				return nil, err
+			}
+			return proto.Marshal(resp)
+		})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return resp, false, err
+		}
+		return resp, false, s.toStatus(ctx, err)
+	}
+	if !replayed {
+		return resp, false, nil
+	}
+
+	resp = resp.ProtoReflect().Type().New().Interface().(T) //nolint:forcetypeassert
+	if err := proto.Unmarshal(stored, resp); err != nil {
+		return resp, false, s.toStatus(ctx, err)
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(replayedHeader, "true"))
+	return resp, true, nil
+}
+
+func incomingIdempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(idempotencyKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,279 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: NotificationChannelService.proto
+
+package pb
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NotificationChannel struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// log, email или http.
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Адрес email или URL для http; для log не нужен.
+	Address       string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificationChannel) Reset() {
+	*x = NotificationChannel{}
+	mi := &file_NotificationChannelService_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationChannel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationChannel) ProtoMessage() {}
+
+func (x *NotificationChannel) ProtoReflect() protoreflect.Message {
+	mi := &file_NotificationChannelService_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationChannel.ProtoReflect.Descriptor instead.
+func (*NotificationChannel) Descriptor() ([]byte, []int) {
+	return file_NotificationChannelService_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NotificationChannel) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *NotificationChannel) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *NotificationChannel) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type GetNotificationChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNotificationChannelRequest) Reset() {
+	*x = GetNotificationChannelRequest{}
+	mi := &file_NotificationChannelService_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNotificationChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNotificationChannelRequest) ProtoMessage() {}
+
+func (x *GetNotificationChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_NotificationChannelService_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNotificationChannelRequest.ProtoReflect.Descriptor instead.
+func (*GetNotificationChannelRequest) Descriptor() ([]byte, []int) {
+	return file_NotificationChannelService_proto_rawDescGZIP(), []int{1}
+}
+
+type SetNotificationChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       *NotificationChannel   `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNotificationChannelRequest) Reset() {
+	*x = SetNotificationChannelRequest{}
+	mi := &file_NotificationChannelService_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNotificationChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNotificationChannelRequest) ProtoMessage() {}
+
+func (x *SetNotificationChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_NotificationChannelService_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNotificationChannelRequest.ProtoReflect.Descriptor instead.
+func (*SetNotificationChannelRequest) Descriptor() ([]byte, []int) {
+	return file_NotificationChannelService_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SetNotificationChannelRequest) GetChannel() *NotificationChannel {
+	if x != nil {
+		return x.Channel
+	}
+	return nil
+}
+
+type ResetNotificationChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetNotificationChannelRequest) Reset() {
+	*x = ResetNotificationChannelRequest{}
+	mi := &file_NotificationChannelService_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetNotificationChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetNotificationChannelRequest) ProtoMessage() {}
+
+func (x *ResetNotificationChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_NotificationChannelService_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetNotificationChannelRequest.ProtoReflect.Descriptor instead.
+func (*ResetNotificationChannelRequest) Descriptor() ([]byte, []int) {
+	return file_NotificationChannelService_proto_rawDescGZIP(), []int{3}
+}
+
+var File_NotificationChannelService_proto protoreflect.FileDescriptor
+
+const file_NotificationChannelService_proto_rawDesc = "" +
+	"\n" +
+	" NotificationChannelService.proto\x12\x05event\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\"\\\n" +
+	"\x13NotificationChannel\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\"\x1f\n" +
+	"\x1dGetNotificationChannelRequest\"U\n" +
+	"\x1dSetNotificationChannelRequest\x124\n" +
+	"\achannel\x18\x01 \x01(\v2\x1a.event.NotificationChannelR\achannel\"!\n" +
+	"\x1fResetNotificationChannelRequest2\x97\x03\n" +
+	"\x1aNotificationChannelService\x12y\n" +
+	"\x16GetNotificationChannel\x12$.event.GetNotificationChannelRequest\x1a\x1a.event.NotificationChannel\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/notification-channel\x12\x82\x01\n" +
+	"\x16SetNotificationChannel\x12$.event.SetNotificationChannelRequest\x1a\x1a.event.NotificationChannel\"&\x82\xd3\xe4\x93\x02 :\achannel\x1a\x15/notification-channel\x12y\n" +
+	"\x18ResetNotificationChannel\x12&.event.ResetNotificationChannelRequest\x1a\x16.google.protobuf.Empty\"\x1d\x82\xd3\xe4\x93\x02\x17*\x15/notification-channelBNZLgithub.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb;pbb\x06proto3"
+
+var (
+	file_NotificationChannelService_proto_rawDescOnce sync.Once
+	file_NotificationChannelService_proto_rawDescData []byte
+)
+
+func file_NotificationChannelService_proto_rawDescGZIP() []byte {
+	file_NotificationChannelService_proto_rawDescOnce.Do(func() {
+		file_NotificationChannelService_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_NotificationChannelService_proto_rawDesc), len(file_NotificationChannelService_proto_rawDesc)))
+	})
+	return file_NotificationChannelService_proto_rawDescData
+}
+
+var file_NotificationChannelService_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_NotificationChannelService_proto_goTypes = []any{
+	(*NotificationChannel)(nil),             // 0: event.NotificationChannel
+	(*GetNotificationChannelRequest)(nil),   // 1: event.GetNotificationChannelRequest
+	(*SetNotificationChannelRequest)(nil),   // 2: event.SetNotificationChannelRequest
+	(*ResetNotificationChannelRequest)(nil), // 3: event.ResetNotificationChannelRequest
+	(*emptypb.Empty)(nil),                   // 4: google.protobuf.Empty
+}
+var file_NotificationChannelService_proto_depIdxs = []int32{
+	0, // 0: event.SetNotificationChannelRequest.channel:type_name -> event.NotificationChannel
+	1, // 1: event.NotificationChannelService.GetNotificationChannel:input_type -> event.GetNotificationChannelRequest
+	2, // 2: event.NotificationChannelService.SetNotificationChannel:input_type -> event.SetNotificationChannelRequest
+	3, // 3: event.NotificationChannelService.ResetNotificationChannel:input_type -> event.ResetNotificationChannelRequest
+	0, // 4: event.NotificationChannelService.GetNotificationChannel:output_type -> event.NotificationChannel
+	0, // 5: event.NotificationChannelService.SetNotificationChannel:output_type -> event.NotificationChannel
+	4, // 6: event.NotificationChannelService.ResetNotificationChannel:output_type -> google.protobuf.Empty
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_NotificationChannelService_proto_init() }
+func file_NotificationChannelService_proto_init() {
+	if File_NotificationChannelService_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_NotificationChannelService_proto_rawDesc), len(file_NotificationChannelService_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_NotificationChannelService_proto_goTypes,
+		DependencyIndexes: file_NotificationChannelService_proto_depIdxs,
+		MessageInfos:      file_NotificationChannelService_proto_msgTypes,
+	}.Build()
+	File_NotificationChannelService_proto = out.File
+	file_NotificationChannelService_proto_goTypes = nil
+	file_NotificationChannelService_proto_depIdxs = nil
+}
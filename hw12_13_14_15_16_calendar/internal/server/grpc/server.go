@@ -0,0 +1,97 @@
+package internalgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
+}
+
+type Server struct {
+	server   *grpc.Server
+	listener net.Listener
+	logger   Logger
+	config   config.ServerConf
+}
+
+func NewServer(
+	logger Logger,
+	metrics Metrics,
+	health HealthChecker,
+	authenticator Authenticator,
+	events pb.EventServiceServer,
+	webhooks pb.WebhookServiceServer,
+	channels pb.NotificationChannelServiceServer,
+	config config.ServerConf,
+) *Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			tracingUnaryInterceptor, metricsUnaryInterceptor(metrics), authUnaryInterceptor(authenticator),
+		),
+		grpc.ChainStreamInterceptor(
+			tracingStreamInterceptor, metricsStreamInterceptor(metrics), authStreamInterceptor(authenticator),
+		),
+	)
+	healthpb.RegisterHealthServer(server, newHealthServer(health))
+	pb.RegisterEventServiceServer(server, events)
+	pb.RegisterWebhookServiceServer(server, webhooks)
+	pb.RegisterNotificationChannelServiceServer(server, channels)
+
+	return &Server{
+		server: server,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Start занимает порт; обслуживание запросов выполняет Serve.
+func (s *Server) Start(_ context.Context) error {
+	addr := net.JoinHostPort(s.config.Host, s.config.GrpcPort)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen %s: %w", addr, err)
+	}
+	s.listener = listener
+
+	return nil
+}
+
+// Serve обслуживает запросы до вызова Stop.
+func (s *Server) Serve() error {
+	s.logger.Info(fmt.Sprintf("gRPC server starting on %s", s.listener.Addr()))
+
+	if err := s.server.Serve(s.listener); err != nil {
+		return fmt.Errorf("gRPC server failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("gRPC server shutting down...")
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+
+	s.logger.Info("gRPC server stopped")
+	return nil
+}
@@ -0,0 +1,130 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type grpcObservation struct {
+	method string
+	code   string
+}
+
+type recordingMetrics struct {
+	mu           sync.Mutex
+	observations []grpcObservation
+}
+
+func (m *recordingMetrics) ObserveGRPCRequest(method, code string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, grpcObservation{method: method, code: code})
+}
+
+func (m *recordingMetrics) all() []grpcObservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]grpcObservation(nil), m.observations...)
+}
+
+// startServer поднимает сервер с полной цепочкой перехватчиков поверх bufconn.
+func startServer(t *testing.T, metrics Metrics, authConf config.AuthConf) *grpc.ClientConn {
+	t.Helper()
+
+	authenticator, err := auth.New(authConf)
+	require.NoError(t, err)
+
+	services := newTestServices()
+	server := NewServer(nopLogger{}, metrics, health.NewChecker(time.Second), authenticator,
+		services.events, services.webhooks, services.channels, config.ServerConf{})
+	listener := bufconn.Listen(1 << 20)
+	server.listener = listener
+	go server.Serve() //nolint:errcheck
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer_Metrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	client := healthpb.NewHealthClient(startServer(t, metrics, config.AuthConf{}))
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Watch не реализован: поток закрывается с Unimplemented.
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	assert.Equal(t, []grpcObservation{
+		{method: "/grpc.health.v1.Health/Check", code: "OK"},
+		{method: "/grpc.health.v1.Health/Check", code: "NotFound"},
+		{method: "/grpc.health.v1.Health/Watch", code: "Unimplemented"},
+	}, metrics.all())
+}
+
+func TestServer_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	client := healthpb.NewHealthClient(startServer(t, &recordingMetrics{}, config.AuthConf{}))
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// Span потока может закрыться уже после того, как клиент получил статус.
+	require.Eventually(t, func() bool { return len(recorder.Ended()) == 2 }, time.Second, 10*time.Millisecond)
+
+	spans := map[string]string{}
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == attribute.Key("rpc.grpc.status_code") {
+				spans[span.Name()] = attr.Value.AsString()
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/grpc.health.v1.Health/Check": "OK",
+		"/grpc.health.v1.Health/Watch": "Unimplemented",
+	}, spans)
+}
@@ -0,0 +1,81 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var tracer = otel.Tracer("github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc")
+
+// metadataCarrier позволяет извлекать traceparent из входящих gRPC-метаданных.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func tracingUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	defer func() { endServerSpan(span, err) }()
+
+	return handler(ctx, req)
+}
+
+// tracingStreamInterceptor ведёт один span на весь поток: от открытия до возврата обработчика.
+func tracingStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	defer func() { endServerSpan(span, err) }()
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	return tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		),
+	)
+}
+
+func endServerSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	tracing.EndSpan(span, err)
+}
@@ -0,0 +1,144 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type WebhookApplication interface {
+	RegisterWebhook(ctx context.Context, hook *domain.Webhook) error
+	ListWebhooks(ctx context.Context, userID int) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id int) error
+	ListWebhookDeliveries(ctx context.Context, userID, id int) ([]domain.WebhookDelivery, error)
+}
+
+var errWebhookRequired = errors.New("webhook is required")
+
+// WebhookService реализует API вебхуков; через шлюз он же обслуживает HTTP.
+type WebhookService struct {
+	pb.UnimplementedWebhookServiceServer
+	logger Logger
+	app    WebhookApplication
+}
+
+func NewWebhookService(logger Logger, app WebhookApplication) *WebhookService {
+	return &WebhookService{logger: logger, app: app}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.Webhook, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetWebhook() == nil {
+		return nil, status.Error(codes.InvalidArgument, errWebhookRequired.Error())
+	}
+
+	hook := domain.Webhook{
+		UserID: userID,
+		URL:    req.GetWebhook().GetUrl(),
+		Secret: req.GetWebhook().GetSecret(),
+	}
+	if err := s.app.RegisterWebhook(ctx, &hook); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	// Секрет возвращается только при регистрации, чтобы клиент мог проверять подпись.
+	resp := webhookToProto(hook)
+	resp.Secret = &hook.Secret
+
+	setHTTPCode(ctx, 201)
+	return resp, nil
+}
+
+func (s *WebhookService) ListWebhooks(
+	ctx context.Context, _ *pb.ListWebhooksRequest,
+) (*pb.ListWebhooksResponse, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := s.app.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &pb.ListWebhooksResponse{Webhooks: make([]*pb.Webhook, len(hooks))}
+	for i, hook := range hooks {
+		resp.Webhooks[i] = webhookToProto(hook)
+	}
+	return resp, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*emptypb.Empty, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.DeleteWebhook(ctx, userID, int(req.GetId())); err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	setHTTPCode(ctx, 204)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *WebhookService) ListWebhookDeliveries(
+	ctx context.Context, req *pb.ListWebhookDeliveriesRequest,
+) (*pb.ListWebhookDeliveriesResponse, error) {
+	userID, err := requestUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.app.ListWebhookDeliveries(ctx, userID, int(req.GetId()))
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+
+	resp := &pb.ListWebhookDeliveriesResponse{Deliveries: make([]*pb.WebhookDelivery, len(deliveries))}
+	for i, d := range deliveries {
+		resp.Deliveries[i] = &pb.WebhookDelivery{
+			Id:         int32(d.ID),        //nolint:gosec
+			WebhookId:  int32(d.WebhookID), //nolint:gosec
+			EventId:    int32(d.EventID),   //nolint:gosec
+			Action:     string(d.Action),
+			Attempt:    int32(d.Attempt),    //nolint:gosec
+			StatusCode: int32(d.StatusCode), //nolint:gosec
+			CreatedAt:  timestamppb.New(d.CreatedAt),
+		}
+		if d.Error != "" {
+			resp.Deliveries[i].Error = &d.Error
+		}
+	}
+	return resp, nil
+}
+
+func (s *WebhookService) toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidWebhookURL):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		s.logger.ErrorContext(ctx, "webhook request failed: "+err.Error())
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func webhookToProto(hook domain.Webhook) *pb.Webhook {
+	return &pb.Webhook{
+		Id:        int32(hook.ID), //nolint:gosec
+		Url:       hook.URL,
+		CreatedAt: timestamppb.New(hook.CreatedAt),
+	}
+}
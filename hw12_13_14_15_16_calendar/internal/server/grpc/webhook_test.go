@@ -0,0 +1,56 @@
+package internalgrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway_Webhooks(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	do := func(userID int, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		withUserHandler(userID, gateway).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(1, http.MethodPost, "/webhooks", `{"url":"ftp://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(1, http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","secret":"s3cret"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.EqualValues(t, 1, created["id"])
+	assert.Equal(t, "s3cret", created["secret"])
+	assert.NotEmpty(t, created["createdAt"])
+
+	// В списке секрет не возвращается.
+	rec = do(1, http.MethodGet, "/webhooks", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var hooks []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hooks))
+	require.Len(t, hooks, 1)
+	assert.NotContains(t, hooks[0], "secret")
+
+	rec = do(1, http.MethodGet, "/webhooks/1/deliveries", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	// Чужой вебхук выглядит как несуществующий.
+	rec = do(2, http.MethodDelete, "/webhooks/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"webhook not found"}`, rec.Body.String())
+
+	rec = do(1, http.MethodDelete, "/webhooks/1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
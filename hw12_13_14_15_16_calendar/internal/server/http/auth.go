@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+type Authenticator interface {
+	Enabled() bool
+	Required(route string) bool
+	Authenticate(ctx context.Context, creds auth.Credentials) (int, error)
+}
+
+// authMiddleware определяет пользователя запроса: по JWT или API-ключу, а при выключенной
+// аутентификации — по заголовку X-User-ID. Отказ в доступе выполняет requireUser на маршруте,
+// чтобы отклонённые запросы тоже попадали в логи и метрики.
+func authMiddleware(authenticator Authenticator, log Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if !authenticator.Enabled() {
+			if userID, err := strconv.Atoi(r.Header.Get(userIDHeader)); err == nil && userID > 0 {
+				ctx = withUser(ctx, userID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		creds := auth.Credentials{
+			BearerToken: auth.ParseAuthorization(r.Header.Get("Authorization")),
+			APIKey:      r.Header.Get(apiKeyHeader),
+		}
+		if !creds.Empty() {
+			userID, err := authenticator.Authenticate(ctx, creds)
+			if err != nil {
+				log.InfoContext(ctx, "authentication failed: "+err.Error())
+			} else {
+				ctx = withUser(ctx, userID)
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func withUser(ctx context.Context, userID int) context.Context {
+	return logger.WithField(auth.WithUserID(ctx, userID), logger.FieldUserID, userID)
+}
+
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.UserID(r.Context()); !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+			writeError(w, http.StatusUnauthorized, auth.ErrUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
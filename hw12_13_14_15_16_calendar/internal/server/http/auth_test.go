@@ -0,0 +1,98 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "secret"
+
+func authOptions() testOptions {
+	return testOptions{auth: config.AuthConf{
+		Enabled: true,
+		JWT: config.JWTConf{
+			Keys: []config.JWTKeyConf{{ID: "main", Algorithm: "HS256", Secret: testJWTSecret}},
+		},
+		APIKeys:      []config.APIKeyConf{{Key: "key-2", UserID: 2}},
+		PublicRoutes: []string{"GET /healthz"},
+	}}
+}
+
+func bearer(t *testing.T, userID int, secret string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userID),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestAuth_RejectsMissingAndBadCredentials(t *testing.T) {
+	s := newTestServer(t, authOptions())
+
+	for name, headers := range map[string]map[string]string{
+		"missing":        nil,
+		"malformed":      {"Authorization": "Bearer not-a-jwt"},
+		"wrong secret":   {"Authorization": bearer(t, 1, "other")},
+		"unknown key":    {apiKeyHeader: "key-3"},
+		"user id header": {userIDHeader: "1"},
+	} {
+		rec := s.do(tagsRequest(headers))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, name)
+		assert.Equal(t, `Bearer realm="calendar"`, rec.Header().Get("WWW-Authenticate"), name)
+	}
+
+	// Отклонённый запрос всё равно попадает в лог доступа.
+	assert.NotEmpty(t, s.entries(t, "http request"))
+}
+
+func TestAuth_AcceptsValidCredentials(t *testing.T) {
+	s := newTestServer(t, authOptions())
+
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(map[string]string{"Authorization": bearer(t, 1, testJWTSecret)})).Code)
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(map[string]string{apiKeyHeader: "key-2"})).Code)
+}
+
+func TestAuth_PublicRoute(t *testing.T) {
+	s := newTestServer(t, authOptions())
+
+	assert.Equal(t, http.StatusOK, s.do(httptest.NewRequest(http.MethodGet, "/healthz", nil)).Code)
+	assert.Equal(t, http.StatusUnauthorized, s.do(httptest.NewRequest(http.MethodGet, "/readyz", nil)).Code)
+}
+
+func TestAuth_OtherUsersEvent(t *testing.T) {
+	s := newTestServer(t, authOptions())
+
+	req := newJSONRequest(http.MethodPost, "/events", testEventBody)
+	req.Header.Set("Authorization", bearer(t, 1, testJWTSecret))
+	rec := s.do(req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	target := "/events/" + strconv.Itoa(created.ID)
+
+	// Чужое событие неотличимо от несуществующего.
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		req = httptest.NewRequest(method, target, nil)
+		req.Header.Set(apiKeyHeader, "key-2")
+		assert.Equal(t, http.StatusNotFound, s.do(req).Code, method)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Authorization", bearer(t, 1, testJWTSecret))
+	assert.Equal(t, http.StatusOK, s.do(req).Code)
+}
@@ -0,0 +1,77 @@
+package internalhttp
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIPResolver определяет адрес клиента. X-Real-IP и X-Forwarded-For может прислать
+// кто угодно, поэтому им верим, только если соединение пришло от доверенного прокси.
+type clientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// newClientIPResolver принимает адреса и подсети в нотации CIDR; некорректные значения
+// отсекает проверка конфигурации, здесь они пропускаются.
+func newClientIPResolver(proxies []string) *clientIPResolver {
+	c := &clientIPResolver{}
+	for _, proxy := range proxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			c.trusted = append(c.trusted, prefix)
+		}
+	}
+	return c
+}
+
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		return netip.ParsePrefix(proxy)
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (c *clientIPResolver) clientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !c.isTrusted(remote) {
+		return remote
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		// Каждый прокси дописывает адрес справа: клиент — первый справа адрес,
+		// который добавил не доверенный прокси.
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || !c.isTrusted(hop) {
+				return hop
+			}
+		}
+	}
+	return remote
+}
+
+func (c *clientIPResolver) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package internalhttp
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Authorization, X-API-Key, X-User-ID, X-Request-ID, Idempotency-Key, traceparent"
+	corsExposeHeaders = "X-Request-ID, Trace-Id, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining"
+)
+
+// corsPolicy хранит список разрешённых Origin, который можно заменить при перезагрузке конфига.
+type corsPolicy struct {
+	origins atomic.Pointer[[]string]
+}
+
+func newCORSPolicy(origins []string) *corsPolicy {
+	p := &corsPolicy{}
+	p.set(origins)
+	return p
+}
+
+func (p *corsPolicy) set(origins []string) {
+	origins = append([]string(nil), origins...)
+	p.origins.Store(&origins)
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	for _, allowed := range *p.origins.Load() {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func corsMiddleware(policy *corsPolicy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := policy.allowed(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		}
+
+		// Preflight-запрос обрабатывается здесь и до маршрутов не доходит.
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
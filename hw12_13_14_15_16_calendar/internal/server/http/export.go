@@ -0,0 +1,48 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/transfer"
+)
+
+// exportErrorTrailer сообщает об ошибке, прервавшей выгрузку: статус к этому моменту уже
+// отправлен, и без трейлера оборванный поток не отличить от полного.
+const exportErrorTrailer = "X-Export-Error"
+
+var exportContentTypes = map[transfer.Format]string{
+	transfer.FormatJSONL: "application/x-ndjson",
+	transfer.FormatICS:   "text/calendar; charset=utf-8",
+}
+
+// exportHandler потоком выгружает события пользователя запроса. Выгрузка бывает долгой,
+// поэтому общий WriteTimeout сервера на неё не распространяется.
+func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	format := transfer.FormatJSONL
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		if format, err = transfer.ParseFormat(raw); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Trailer", exportErrorTrailer)
+
+	n, err := transfer.Export(r.Context(), s.exporter, userID, w, format, nil)
+	if err != nil {
+		w.Header().Set(exportErrorTrailer, err.Error())
+		s.logger.ErrorContext(r.Context(), fmt.Sprintf("export failed after %d events: %v", n, err))
+		return
+	}
+	s.logger.InfoContext(r.Context(), fmt.Sprintf("exported %d events", n))
+}
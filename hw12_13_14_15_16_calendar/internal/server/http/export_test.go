@@ -0,0 +1,94 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/transfer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errList = errors.New("list failed")
+
+type failingExporter struct{}
+
+func (failingExporter) Event() storage.EventRepository { return failingEvents{} }
+
+type failingEvents struct {
+	storage.EventRepository
+}
+
+func (failingEvents) ListAfter(context.Context, int, int, int) ([]domain.Event, error) {
+	return nil, errList
+}
+
+func exportRequest(t *testing.T, exporter transfer.Storage, userID int, target string) *http.Response {
+	t.Helper()
+
+	logg, err := logger.New(config.LoggerConf{Level: "error", Output: logger.OutputStdout})
+	require.NoError(t, err)
+	logg.SetOutput(io.Discard)
+
+	server := &Server{logger: logg, exporter: exporter}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if userID != 0 {
+		req = req.WithContext(auth.WithUserID(req.Context(), userID))
+	}
+	server.exportHandler(rec, req)
+	return rec.Result()
+}
+
+func TestServer_Export(t *testing.T) {
+	memory := memorystorage.NewStorage()
+	for i, title := range []string{"Standup", "Retro", "Planning"} {
+		event := &domain.Event{
+			Title: title, EventTime: time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC), Duration: time.Hour, UserID: i/2 + 1,
+		}
+		require.NoError(t, memory.Event().Create(context.Background(), event))
+	}
+
+	resp := exportRequest(t, memory, 1, "/events:export")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.Len(t, strings.Split(strings.TrimSpace(string(body)), "\n"), 2)
+	assert.Empty(t, resp.Trailer.Get(exportErrorTrailer))
+
+	resp = exportRequest(t, memory, 2, "/events:export?format=ics")
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "SUMMARY:Planning")
+	assert.NotContains(t, string(body), "SUMMARY:Retro")
+
+	resp = exportRequest(t, memory, 1, "/events:export?format=csv")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = exportRequest(t, memory, 0, "/events:export")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_ExportFailureTrailer(t *testing.T) {
+	resp := exportRequest(t, failingExporter{}, 1, "/events:export")
+	_, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	// Статус уже отправлен, ошибку видно только в трейлере.
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Trailer.Get(exportErrorTrailer), errList.Error())
+}
@@ -0,0 +1,18 @@
+package internalhttp
+
+import "net/http"
+
+func (s *Server) healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.health.Live())
+}
+
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Ready(r.Context())
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, report)
+}
@@ -1,9 +1,18 @@
 package internalhttp
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
 )
 
 type responseWriter struct {
@@ -16,7 +25,26 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func loggingMiddleware(logger Logger, next http.Handler) http.Handler {
+// Unwrap даёт http.ResponseController добраться до исходного соединения.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// requestIDMiddleware берёт идентификатор запроса из X-Request-ID или генерирует новый
+// и кладёт его в поля логов контекста.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(logger.WithField(r.Context(), logger.FieldRequestID, requestID)))
+	})
+}
+
+func loggingMiddleware(log Logger, clientIP *clientIPResolver, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -27,35 +55,50 @@ func loggingMiddleware(logger Logger, next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrappedWriter, r)
 
-		latency := time.Since(start)
-		userAgent := r.UserAgent()
-		if userAgent == "" {
-			userAgent = "-"
-		}
+		ctx := logger.WithFields(r.Context(), logger.Fields{
+			"client_ip":  clientIP.clientIP(r),
+			"method":     r.Method,
+			"uri":        r.URL.RequestURI(),
+			"proto":      r.Proto,
+			"status":     wrappedWriter.statusCode,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"user_agent": r.UserAgent(),
+			"http_route": routeLabel(r),
+		})
 
-		timestamp := start.Format("02/Jan/2006:15:04:05 -0700")
+		log.InfoContext(ctx, "http request")
+	})
+}
+
+func metricsMiddleware(metrics Metrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		wrappedWriter := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
 
-		logEntry := fmt.Sprintf("%s [%s] %s %s %s %d %d \"%s\"",
-			getClientIP(r),
-			timestamp,
-			r.Method,
-			r.URL.RequestURI(),
-			r.Proto,
-			wrappedWriter.statusCode,
-			latency.Milliseconds(),
-			userAgent,
-		)
+		next.ServeHTTP(wrappedWriter, r)
 
-		logger.Info(logEntry)
+		metrics.ObserveHTTPRequest(routeLabel(r), r.Method, wrappedWriter.statusCode, time.Since(start))
 	})
 }
 
-func getClientIP(r *http.Request) string {
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		return realIP
+// routeLabel возвращает шаблон маршрута без метода, чтобы не плодить метки по ID.
+func routeLabel(r *http.Request) string {
+	pattern := r.Pattern
+	if pattern == "" {
+		return "unmatched"
 	}
-	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
-		return forwardedFor
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
 	}
-	return r.RemoteAddr
+	return pattern
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
 }
@@ -0,0 +1,67 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventBody = `{"title":"Standup","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`
+
+func TestRequestID_PropagatesToLogs(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+
+	req := newJSONRequest(http.MethodPost, "/events", testEventBody)
+	req.Header.Set(requestIDHeader, "req-42")
+	req.Header.Set(userIDHeader, "7")
+	rec := s.do(req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "req-42", rec.Header().Get(requestIDHeader))
+
+	access := s.entries(t, "http request")
+	require.Len(t, access, 1)
+	assert.Equal(t, "req-42", access[0]["request_id"])
+	assert.EqualValues(t, 7, access[0]["user_id"])
+	assert.EqualValues(t, http.StatusCreated, access[0]["status"])
+	assert.Equal(t, "/events", access[0]["http_route"])
+
+	created := s.entries(t, "event created")
+	require.Len(t, created, 1)
+	assert.Equal(t, "req-42", created[0]["request_id"])
+	assert.EqualValues(t, 7, created[0]["user_id"])
+	assert.EqualValues(t, 1, created[0]["event_id"])
+
+	var storageCreate map[string]interface{}
+	for _, entry := range s.entries(t, "storage operation") {
+		if entry["storage_operation"] == "event_create" {
+			storageCreate = entry
+		}
+	}
+	require.NotNil(t, storageCreate)
+	assert.Equal(t, "req-42", storageCreate["request_id"])
+	assert.EqualValues(t, 7, storageCreate["user_id"])
+	assert.EqualValues(t, 1, storageCreate["event_id"])
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+
+	for _, header := range []string{"", strings.Repeat("x", maxRequestIDLength+1)} {
+		s.logs.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		if header != "" {
+			req.Header.Set(requestIDHeader, header)
+		}
+		rec := s.do(req)
+
+		requestID := rec.Header().Get(requestIDHeader)
+		assert.Len(t, requestID, 32)
+		access := s.entries(t, "http request")
+		require.Len(t, access, 1)
+		assert.Equal(t, requestID, access[0]["request_id"])
+	}
+}
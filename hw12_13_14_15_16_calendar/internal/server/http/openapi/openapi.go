@@ -0,0 +1,43 @@
+// Package openapi содержит спецификацию HTTP API и Swagger UI для неё.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/swaggest/swgui/v5emb"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+// Load разбирает встроенную спецификацию и проверяет её корректность.
+func Load(ctx context.Context) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+
+	doc, err := loader.LoadFromData(Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return doc, nil
+}
+
+// SpecHandler отдаёт спецификацию в JSON.
+func SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(Spec)
+	})
+}
+
+// UIHandler отдаёт Swagger UI со встроенными в бинарник ресурсами.
+func UIHandler(specPath, basePath string) http.Handler {
+	return v5emb.New("Calendar API", specPath, basePath)
+}
@@ -0,0 +1,19 @@
+package openapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load(context.Background())
+	require.NoError(t, err)
+
+	for _, path := range []string{"/events", "/events/{id}", "/events:export", "/healthz", "/readyz"} {
+		assert.NotNil(t, doc.Paths.Find(path), path)
+	}
+	assert.NotNil(t, doc.Components.Schemas["Event"])
+}
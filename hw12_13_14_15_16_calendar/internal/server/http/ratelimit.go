@@ -0,0 +1,102 @@
+package internalhttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+)
+
+// routeGroup объединяет маршруты с общими лимитами.
+type routeGroup string
+
+const (
+	groupEvents   routeGroup = "events"
+	groupWebhooks routeGroup = "webhooks"
+	groupChannels routeGroup = "channels"
+)
+
+// rateLimiter применяет лимиты групп маршрутов; конфигурацию можно заменить на лету.
+type rateLimiter struct {
+	store    ratelimit.Store
+	conf     atomic.Pointer[config.RateLimitConf]
+	log      Logger
+	auth     Authenticator
+	clientIP *clientIPResolver
+}
+
+func newRateLimiter(
+	store ratelimit.Store, conf config.RateLimitConf, log Logger, auth Authenticator, clientIP *clientIPResolver,
+) *rateLimiter {
+	l := &rateLimiter{store: store, log: log, auth: auth, clientIP: clientIP}
+	l.set(conf)
+	return l
+}
+
+func (l *rateLimiter) set(conf config.RateLimitConf) {
+	l.conf.Store(&conf)
+}
+
+func (l *rateLimiter) limits(group routeGroup) (bool, config.RouteLimitConf) {
+	conf := l.conf.Load()
+	switch group {
+	case groupEvents:
+		return conf.Enabled, conf.Events
+	case groupWebhooks:
+		return conf.Enabled, conf.Webhooks
+	default:
+		return conf.Enabled, conf.Channels
+	}
+}
+
+// middleware ограничивает частоту запросов по пользователю (или IP, если пользователь
+// не аутентифицирован) и размер тела запроса для группы маршрутов.
+func (l *rateLimiter) middleware(group routeGroup, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enabled, limit := l.limits(group)
+
+		if limit.MaxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit.MaxBodyBytes)
+		}
+
+		if !enabled {
+			next(w, r)
+			return
+		}
+
+		res, err := l.store.Allow(r.Context(), string(group)+":"+l.key(r),
+			ratelimit.Limit{Rate: limit.RequestsPerSecond, Burst: limit.Burst}, time.Now())
+		if err != nil {
+			// При недоступности хранилища лимитов запросы пропускаются.
+			l.log.ErrorContext(r.Context(), "rate limiter failed: "+err.Error())
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, errRateLimited)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// key выбирает, чей лимит расходует запрос. Пользователь из X-User-ID при выключенной
+// аутентификации не подтверждён: меняя заголовок, клиент получал бы новый лимит.
+func (l *rateLimiter) key(r *http.Request) string {
+	if l.auth.Enabled() {
+		if userID, err := userIDFromRequest(r); err == nil {
+			return "user:" + strconv.Itoa(userID)
+		}
+	}
+	return "ip:" + l.clientIP.clientIP(r)
+}
@@ -0,0 +1,137 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func limitedOptions(burst int) testOptions {
+	limit := config.RouteLimitConf{RequestsPerSecond: 0.01, Burst: burst}
+	return testOptions{rateLimit: config.RateLimitConf{Enabled: true, Events: limit, Webhooks: limit, Channels: limit}}
+}
+
+func tagsRequest(headers map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
+func TestRateLimit_TooManyRequests(t *testing.T) {
+	s := newTestServer(t, limitedOptions(2))
+	headers := map[string]string{userIDHeader: "1"}
+
+	for i := 0; i < 2; i++ {
+		rec := s.do(tagsRequest(headers))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+	}
+
+	rec := s.do(tagsRequest(headers))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "100", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"rate limit exceeded"}`, rec.Body.String())
+
+	// Другая группа маршрутов расходует свой лимит.
+	rec = s.do(httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	assert.NotEqual(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestRateLimit_KeyNotChosenByClient(t *testing.T) {
+	s := newTestServer(t, limitedOptions(1))
+	require.Equal(t, http.StatusOK, s.do(tagsRequest(map[string]string{userIDHeader: "1"})).Code)
+
+	// Без аутентификации и доверенного прокси лимит считается по адресу соединения.
+	for _, headers := range []map[string]string{
+		{userIDHeader: "2"},
+		{userIDHeader: "1", "X-Forwarded-For": "203.0.113.7"},
+		{userIDHeader: "1", "X-Real-IP": "203.0.113.8"},
+	} {
+		assert.Equal(t, http.StatusTooManyRequests, s.do(tagsRequest(headers)).Code, headers)
+	}
+}
+
+func TestRateLimit_AuthenticatedUsers(t *testing.T) {
+	opts := limitedOptions(1)
+	opts.auth = config.AuthConf{
+		Enabled: true,
+		APIKeys: []config.APIKeyConf{{Key: "key-1", UserID: 1}, {Key: "key-2", UserID: 2}},
+	}
+	s := newTestServer(t, opts)
+
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(map[string]string{apiKeyHeader: "key-1"})).Code)
+	assert.Equal(t, http.StatusTooManyRequests, s.do(tagsRequest(map[string]string{apiKeyHeader: "key-1"})).Code)
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(map[string]string{apiKeyHeader: "key-2"})).Code)
+}
+
+func TestRateLimit_TrustedProxy(t *testing.T) {
+	opts := limitedOptions(1)
+	// httptest.NewRequest приходит с адреса 192.0.2.1.
+	opts.server.TrustedProxies = []string{"192.0.2.0/24"}
+	s := newTestServer(t, opts)
+	forwardedFor := func(ip string) map[string]string {
+		return map[string]string{userIDHeader: "1", "X-Forwarded-For": ip}
+	}
+
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(forwardedFor("203.0.113.7"))).Code)
+	assert.Equal(t, http.StatusTooManyRequests, s.do(tagsRequest(forwardedFor("203.0.113.7"))).Code)
+	assert.Equal(t, http.StatusOK, s.do(tagsRequest(forwardedFor("203.0.113.8"))).Code)
+}
+
+func TestBodyLimit(t *testing.T) {
+	s := newTestServer(t, testOptions{rateLimit: config.RateLimitConf{
+		Events:   config.RouteLimitConf{MaxBodyBytes: 128},
+		Webhooks: config.RouteLimitConf{MaxBodyBytes: 128},
+	}})
+
+	body := `{"title":"` + strings.Repeat("x", 200) + `","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M"}`
+	req := newJSONRequest(http.MethodPost, "/events", body)
+	req.Header.Set(userIDHeader, "1")
+	rec := s.do(req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.JSONEq(t, `{"error":"request body too large"}`, rec.Body.String())
+
+	req = newJSONRequest(http.MethodPost, "/events", `{"title":"a","eventTime":"2025-11-05T10:00:00Z","duration":"PT1M"}`)
+	req.Header.Set(userIDHeader, "1")
+	assert.Equal(t, http.StatusCreated, s.do(req).Code)
+
+	req = newJSONRequest(http.MethodPost, "/webhooks", `{"url":"https://example.com/`+strings.Repeat("x", 200)+`"}`)
+	req.Header.Set(userIDHeader, "1")
+	rec = s.do(req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.JSONEq(t, `{"error":"request body too large"}`, rec.Body.String())
+}
+
+func TestClientIP(t *testing.T) {
+	resolver := newClientIPResolver([]string{"10.0.0.0/8", "192.0.2.1"})
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"direct", "198.51.100.1:1234", nil, "198.51.100.1"},
+		{"untrusted forwarded", "198.51.100.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "198.51.100.1"},
+		{"real ip", "192.0.2.1:1234", map[string]string{"X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+		{"proxy chain", "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.7, 10.0.0.3"},
+			"203.0.113.7"},
+		{"only proxies", "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"}, "10.0.0.4"},
+		{"no headers", "10.0.0.2:1234", nil, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tagsRequest(tt.headers)
+			req.RemoteAddr = tt.remote
+			assert.Equal(t, tt.want, resolver.clientIP(req))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+)
+
+const userIDHeader = "X-User-ID"
+
+var (
+	errInvalidUserID = errors.New("missing or invalid " + userIDHeader + " header")
+	errRateLimited   = errors.New("rate limit exceeded")
+	errBodyTooLarge  = errors.New("request body too large")
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// userIDFromRequest возвращает пользователя, определённого authMiddleware.
+func userIDFromRequest(r *http.Request) (int, error) {
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		return 0, errInvalidUserID
+	}
+	return userID, nil
+}
@@ -2,53 +2,105 @@ package internalhttp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
-	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/http/openapi"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/transfer"
 )
 
 type Server struct {
-	server *http.Server
-	logger Logger
-	app    Application
-	config config.ServerConf
+	server          *http.Server
+	metricsServer   *http.Server
+	listener        net.Listener
+	metricsListener net.Listener
+	logger          Logger
+	metrics         Metrics
+	health          HealthChecker
+	cors            *corsPolicy
+	limiter         *rateLimiter
+	clientIP        *clientIPResolver
+	auth            Authenticator
+	validator       *requestValidator
+	gateway         http.Handler
+	exporter        transfer.Storage
+	config          config.ServerConf
 }
 
 type Logger interface {
 	Info(args ...interface{})
 	Error(args ...interface{})
+	InfoContext(ctx context.Context, args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
 }
 
-type Application interface {
-	CreateEvent(event *domain.Event) error
-	GetEvent(id int) (domain.Event, error)
-	UpdateEvent(id int, event *domain.Event) error
-	DeleteEvent(id int) error
-	ListByDay(date time.Time) ([]domain.Event, error)
-	ListByWeek(date time.Time) ([]domain.Event, error)
-	ListByMonth(date time.Time) ([]domain.Event, error)
+type Metrics interface {
+	ObserveHTTPRequest(route, method string, status int, duration time.Duration)
+	Handler() http.Handler
 }
 
-func NewServer(logger *logger.Logger, app Application, config config.ServerConf) *Server {
+type HealthChecker interface {
+	Live() health.Report
+	Ready(ctx context.Context) health.Report
+}
+
+func NewServer(
+	logger *logger.Logger,
+	metrics Metrics,
+	health HealthChecker,
+	config config.ServerConf,
+	cors config.CORSConf,
+	rateLimit config.RateLimitConf,
+	limitStore ratelimit.Store,
+	authenticator Authenticator,
+	gateway http.Handler,
+	exporter transfer.Storage,
+) *Server {
+	clientIP := newClientIPResolver(config.TrustedProxies)
+
 	return &Server{
-		logger: logger,
-		app:    app,
-		config: config,
+		logger:   logger,
+		metrics:  metrics,
+		health:   health,
+		cors:     newCORSPolicy(cors.AllowedOrigins),
+		limiter:  newRateLimiter(limitStore, rateLimit, logger, authenticator, clientIP),
+		clientIP: clientIP,
+		auth:     authenticator,
+		gateway:  gateway,
+		exporter: exporter,
+		config:   config,
 	}
 }
 
-func (s *Server) Start(ctx context.Context) error {
-	mux := http.NewServeMux()
+// SetCORSOrigins заменяет список разрешённых Origin без перезапуска сервера.
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.cors.set(origins)
+}
 
-	mux.HandleFunc("/", s.helloHandler)
-	mux.HandleFunc("/hello", s.helloHandler)
+// SetRateLimits заменяет лимиты запросов без перезапуска сервера.
+func (s *Server) SetRateLimits(conf config.RateLimitConf) {
+	s.limiter.set(conf)
+}
 
-	handler := loggingMiddleware(s.logger, mux)
+// Start настраивает маршруты и занимает порты; обслуживание запросов выполняет Serve.
+func (s *Server) Start(ctx context.Context) error {
+	handler, err := s.routes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.config.MetricsAddr != "" {
+		if err := s.listenMetrics(); err != nil {
+			return err
+		}
+	}
 
 	s.server = &http.Server{
 		Addr:         net.JoinHostPort(s.config.Host, s.config.Port),
@@ -58,21 +110,149 @@ func (s *Server) Start(ctx context.Context) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		if s.metricsListener != nil {
+			s.metricsListener.Close()
+		}
+		return fmt.Errorf("failed to listen %s: %w", s.server.Addr, err)
+	}
+	s.listener = listener
+
+	return nil
+}
+
+// routes собирает маршруты и цепочку middleware основного сервера.
+func (s *Server) routes(ctx context.Context) (http.Handler, error) {
+	validator, err := newRequestValidator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.validator = validator
+
+	mux := http.NewServeMux()
+
+	s.handle(mux, "/", http.HandlerFunc(s.helloHandler))
+	s.handle(mux, "/hello", http.HandlerFunc(s.helloHandler))
+
+	s.handle(mux, "GET /healthz", http.HandlerFunc(s.healthzHandler))
+	s.handle(mux, "GET /readyz", http.HandlerFunc(s.readyzHandler))
+
+	s.handle(mux, "GET /openapi.json", openapi.SpecHandler())
+	s.handle(mux, "GET /docs/", openapi.UIHandler("/openapi.json", "/docs/"))
+
+	// API обслуживает шлюз к gRPC-сервисам, маршруты повторяют аннотации proto.
+	for group, patterns := range map[routeGroup][]string{
+		groupEvents: {
+			"POST /events", "GET /events", "GET /events/{id}", "PUT /events/{id}", "DELETE /events/{id}",
+			"GET /events:search", "GET /tags",
+			"POST /events:batchCreate", "POST /events:batchUpdate", "POST /events:batchDelete",
+		},
+		groupWebhooks: {
+			"POST /webhooks", "GET /webhooks", "DELETE /webhooks/{id}", "GET /webhooks/{id}/deliveries",
+		},
+		groupChannels: {
+			"GET /notification-channel", "PUT /notification-channel", "DELETE /notification-channel",
+		},
+	} {
+		for _, pattern := range patterns {
+			s.handleLimited(mux, group, pattern, s.gateway.ServeHTTP)
+		}
+	}
+	// Выгрузку отдаёт потоком сам сервер: через шлюз пришлось бы собирать её в памяти целиком.
+	s.handleLimited(mux, groupEvents, "GET /events:export", s.exportHandler)
+
+	// Без отдельного адреса метрики отдаются основным сервером.
+	if s.config.MetricsAddr == "" {
+		s.handle(mux, "GET /metrics", s.metrics.Handler())
+	}
+
+	var handler http.Handler = mux
+	handler = corsMiddleware(s.cors, handler)
+	handler = metricsMiddleware(s.metrics, handler)
+	handler = loggingMiddleware(s.logger, s.clientIP, handler)
+	handler = authMiddleware(s.auth, s.logger, handler)
+	handler = requestIDMiddleware(handler)
+	handler = tracingMiddleware(s.clientIP, handler)
+
+	return handler, nil
+}
+
+// handle регистрирует маршрут; непубличные маршруты при включённой аутентификации
+// доступны только аутентифицированным пользователям.
+func (s *Server) handle(mux *http.ServeMux, pattern string, handler http.Handler) {
+	if s.auth.Required(pattern) {
+		handler = requireUser(handler)
+	}
+	mux.Handle(pattern, handler)
+}
+
+func (s *Server) handleLimited(mux *http.ServeMux, group routeGroup, pattern string, handler http.HandlerFunc) {
+	s.handle(mux, pattern, s.limiter.middleware(group, s.validator.middleware(handler)))
+}
+
+func (s *Server) listenMetrics() error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.metrics.Handler())
+
+	s.metricsServer = &http.Server{
+		Addr:              s.config.MetricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", s.metricsServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen %s: %w", s.metricsServer.Addr, err)
+	}
+	s.metricsListener = listener
+
+	return nil
+}
+
+// Serve обслуживает запросы до вызова Stop. Ошибка любого из серверов (основного
+// или метрик) возвращается сразу.
+func (s *Server) Serve() error {
+	errs := make(chan error, 2)
+	servers := 1
+
 	go func() {
 		s.logger.Info(fmt.Sprintf("HTTP server starting on %s", s.server.Addr))
+		errs <- serve(s.server, s.listener, "HTTP server")
+	}()
 
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Error(fmt.Sprintf("HTTP server failed: %v", err))
+	if s.metricsServer != nil {
+		servers++
+		go func() {
+			s.logger.Info(fmt.Sprintf("metrics server starting on %s", s.metricsServer.Addr))
+			errs <- serve(s.metricsServer, s.metricsListener, "metrics server")
+		}()
+	}
+
+	for i := 0; i < servers; i++ {
+		if err := <-errs; err != nil {
+			return err
 		}
-	}()
+	}
+	return nil
+}
 
-	<-ctx.Done()
+func serve(server *http.Server, listener net.Listener, name string) error {
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
 	return nil
 }
 
 func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("HTTP server shutting down...")
 
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("metrics server shutdown error: %w", err)
+		}
+	}
+
 	if s.server != nil {
 		if err := s.server.Shutdown(ctx); err != nil {
 			return fmt.Errorf("HTTP server shutdown error: %w", err)
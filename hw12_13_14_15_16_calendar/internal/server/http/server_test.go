@@ -0,0 +1,98 @@
+package internalhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/auth"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/health"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/idempotency"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	internalgrpc "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/grpc"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, domain.EventAction, domain.Event) {}
+
+type testOptions struct {
+	server    config.ServerConf
+	auth      config.AuthConf
+	rateLimit config.RateLimitConf
+}
+
+// testServer — основной обработчик сервера с приложением поверх хранилища в памяти
+// и логом в формате JSON, который пишется в буфер.
+type testServer struct {
+	handler http.Handler
+	logs    *bytes.Buffer
+}
+
+func newTestServer(t *testing.T, opts testOptions) *testServer {
+	t.Helper()
+
+	logg, err := logger.New(config.LoggerConf{Level: "debug", Format: logger.FormatJSON, Output: logger.OutputStdout})
+	require.NoError(t, err)
+	logs := &bytes.Buffer{}
+	logg.SetOutput(logs)
+
+	appMetrics := metrics.New()
+	storage := metrics.InstrumentStorage(memorystorage.NewStorage(), appMetrics, logg, "memory")
+	calendar := app.New(logg, storage, nopPublisher{}, nil)
+	guard := idempotency.New(logg, storage, config.IdempotencyConf{TTL: time.Hour})
+
+	gateway, err := internalgrpc.NewGateway(context.Background(), internalgrpc.NewEventService(logg, calendar, guard),
+		internalgrpc.NewWebhookService(logg, calendar), internalgrpc.NewChannelService(logg, calendar))
+	require.NoError(t, err)
+	authenticator, err := auth.New(opts.auth)
+	require.NoError(t, err)
+
+	server := NewServer(logg, appMetrics, health.NewChecker(time.Second), opts.server, config.CORSConf{},
+		opts.rateLimit, ratelimit.NewMemoryStore(time.Minute), authenticator, gateway, storage)
+	handler, err := server.routes(context.Background())
+	require.NoError(t, err)
+
+	return &testServer{handler: handler, logs: logs}
+}
+
+func newJSONRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func (s *testServer) do(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// entries возвращает записи лога с сообщением msg.
+func (s *testServer) entries(t *testing.T, msg string) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(s.logs.Bytes()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		if entry["msg"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,52 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/http")
+
+// tracingMiddleware продолжает трейс из заголовка traceparent или начинает новый.
+func tracingMiddleware(clientIP *clientIPResolver, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", clientIP.clientIP(r)),
+			),
+		)
+		defer span.End()
+
+		wrappedWriter := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			w.Header().Set("Trace-Id", traceID)
+		}
+
+		// Шаблон маршрута проставляется mux'ом в переданный запрос, поэтому имя спана задаётся после обработки.
+		req := r.WithContext(ctx)
+		next.ServeHTTP(wrappedWriter, req)
+
+		route := routeLabel(req)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", wrappedWriter.statusCode),
+		)
+		if wrappedWriter.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrappedWriter.statusCode))
+		}
+	})
+}
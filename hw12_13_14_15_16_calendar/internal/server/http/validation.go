@@ -0,0 +1,85 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/server/http/openapi"
+)
+
+// requestValidator проверяет параметры и тело запроса по спецификации OpenAPI.
+type requestValidator struct {
+	router routers.Router
+}
+
+func newRequestValidator(ctx context.Context) (*requestValidator, error) {
+	doc, err := openapi.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &requestValidator{router: router}, nil
+}
+
+func (v *requestValidator) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// Маршрута нет в спецификации — проверять нечего.
+			next(w, r)
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// Аутентификацию выполняет authMiddleware.
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				// id и userId из ответа можно вернуть в PUT как есть: сервер их игнорирует.
+				ExcludeReadOnlyValidations: true,
+			},
+		})
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			} else {
+				writeError(w, http.StatusBadRequest, errors.New(validationMessage(err)))
+			}
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// validationMessage сокращает ошибку валидации до поля и причины, без дампа схемы.
+func validationMessage(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return err.Error()
+	}
+
+	msg := schemaErr.Reason
+	if path := schemaErr.JSONPointer(); len(path) > 0 {
+		msg = strings.Join(path, ".") + ": " + msg
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Parameter != nil {
+		return "parameter " + reqErr.Parameter.Name + ": " + msg
+	}
+	return "request body: " + msg
+}
@@ -0,0 +1,127 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userRequest(method, target, body string) *http.Request {
+	req := newJSONRequest(method, target, body)
+	req.Header.Set(userIDHeader, "1")
+	return req
+}
+
+func TestValidation_RejectsInvalidRequests(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"missing title", userRequest(http.MethodPost, "/events", `{"eventTime":"2025-11-05T10:00:00Z","duration":"PT1H"}`),
+			"title"},
+		{"wrong type", userRequest(http.MethodPost, "/events",
+			`{"title":1,"eventTime":"2025-11-05T10:00:00Z","duration":"PT1H"}`), "request body: title"},
+		{"bad color", userRequest(http.MethodPost, "/events",
+			`{"title":"a","eventTime":"2025-11-05T10:00:00Z","duration":"PT1H","color":"red"}`), "color"},
+		{"bad parameter", userRequest(http.MethodGet, "/events?date=2025-11-05&period=year", ""), "parameter period"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var body errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Contains(t, body.Error, tt.want)
+			assert.NotContains(t, body.Error, "Schema:", "ошибка не должна содержать дамп схемы")
+		})
+	}
+}
+
+// TestValidation_ResponsesMatchSpec проверяет ответы API по той же спецификации,
+// по которой middleware проверяет запросы.
+func TestValidation_ResponsesMatchSpec(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+	validator, err := newRequestValidator(context.Background())
+	require.NoError(t, err)
+
+	requests := []*http.Request{
+		userRequest(http.MethodPost, "/events", `{"title":"Standup","eventTime":"2025-11-05T10:00:00Z",
+			"duration":"PT15M","reminders":[{"offset":"PT10M"}],"tags":["work"]}`),
+		userRequest(http.MethodPost, "/events", `{"title":"Offsite","eventTime":"2025-11-06T00:00:00Z",
+			"allDay":true,"startDate":"2025-11-06","endDate":"2025-11-07"}`),
+		userRequest(http.MethodGet, "/events/1", ""),
+		userRequest(http.MethodGet, "/events?date=2025-11-05&period=week", ""),
+		userRequest(http.MethodPut, "/events/1", `{"title":"Standup","eventTime":"2025-11-05T11:00:00Z","duration":"PT30M"}`),
+		userRequest(http.MethodGet, "/events:search?q=standup", ""),
+		userRequest(http.MethodGet, "/tags", ""),
+		userRequest(http.MethodDelete, "/events/1", ""),
+		userRequest(http.MethodGet, "/events/1", ""),
+		userRequest(http.MethodPost, "/webhooks", `{"url":"https://example.com/hook"}`),
+		userRequest(http.MethodGet, "/webhooks", ""),
+		userRequest(http.MethodGet, "/webhooks/1/deliveries", ""),
+		userRequest(http.MethodDelete, "/webhooks/1", ""),
+		userRequest(http.MethodDelete, "/webhooks/1", ""),
+		userRequest(http.MethodGet, "/notification-channel", ""),
+		userRequest(http.MethodPut, "/notification-channel", `{"type":"email","address":"user@example.com"}`),
+		userRequest(http.MethodDelete, "/notification-channel", ""),
+	}
+	for _, req := range requests {
+		body := ""
+		if req.Body != nil {
+			raw, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			body = string(raw)
+			req.Body = io.NopCloser(strings.NewReader(body))
+		}
+		rec := s.do(req)
+		require.Less(t, rec.Code, http.StatusInternalServerError, rec.Body.String())
+
+		route, pathParams, err := validator.router.FindRoute(httptest.NewRequest(req.Method, req.URL.String(), nil))
+		require.NoError(t, err, req.URL)
+		err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+			},
+			Status: rec.Code,
+			Header: rec.Header(),
+			Body:   io.NopCloser(rec.Body),
+			Options: &openapi3filter.Options{
+				IncludeResponseStatus: true,
+			},
+		})
+		assert.NoError(t, err, "%s %s: %d", req.Method, req.URL, rec.Code)
+	}
+}
+
+func TestValidation_TimeToNotifyIsDerived(t *testing.T) {
+	s := newTestServer(t, testOptions{})
+
+	rec := s.do(userRequest(http.MethodPost, "/events", `{"title":"Standup","eventTime":"2025-11-05T10:00:00Z",
+		"duration":"PT15M","timeToNotify":"2020-01-01T00:00:00Z","reminders":[{"offset":"PT10M"},{"offset":"PT1H"}]}`))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "2025-11-05T09:00:00Z", created["timeToNotify"])
+
+	rec = s.do(userRequest(http.MethodPut, "/events/1",
+		`{"title":"Standup","eventTime":"2025-11-05T10:00:00Z","duration":"PT15M","timeToNotify":"2020-01-01T00:00:00Z"}`))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var updated map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+	assert.NotContains(t, updated, "timeToNotify")
+}
@@ -0,0 +1,133 @@
+package cachestorage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru — ограниченный по числу записей кеш с TTL. Списки событий хранятся вместе
+// с интервалом [start, end), по которому они выбраны, чтобы при изменении события
+// удалять только те списки, в которые оно попадало.
+type lru struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	items    map[string]*list.Element
+	order    *list.List
+	// generation растёт при каждой инвалидации; значение, прочитанное из хранилища
+	// до инвалидации, может быть устаревшим и в кеш не попадает.
+	generation uint64
+}
+
+type entry struct {
+	key        string
+	value      any
+	expiresAt  time.Time
+	isList     bool
+	start, end time.Time
+}
+
+func newLRU(capacity int, ttl time.Duration) *lru {
+	return &lru{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		items:    make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *lru) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry) //nolint:forcetypeassert
+	if !c.now().Before(e.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// gen возвращает текущее поколение; его нужно прочитать до обращения к хранилищу.
+func (c *lru) gen() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// set сохраняет значение, если с момента чтения поколения gen ничего не инвалидировалось.
+func (c *lru) set(gen uint64, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.generation {
+		return
+	}
+
+	e.expiresAt = c.now().Add(c.ttl)
+	if elem, ok := c.items[e.key]; ok {
+		elem.Value = e
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[e.key] = c.order.PushFront(e)
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate удаляет события с указанными ID и списки, для интервала которых affects
+// возвращает true.
+func (c *lru) invalidate(ids []int, affects func(start, end time.Time) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, id := range ids {
+		if elem, ok := c.items[eventKey(id)]; ok {
+			c.remove(elem)
+		}
+	}
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*entry) //nolint:forcetypeassert
+		if e.isList && affects(e.start, e.end) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *lru) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = make(map[string]*list.Element, c.capacity)
+	c.order.Init()
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *lru) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key) //nolint:forcetypeassert
+}
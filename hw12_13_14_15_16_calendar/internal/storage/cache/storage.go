@@ -0,0 +1,249 @@
+package cachestorage
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Metrics interface {
+	ObserveCacheLookup(operation string, hit bool)
+}
+
+// Storage кеширует события по ID и списки пользователей за день, неделю и месяц. Остальные запросы
+// и репозитории проходят в хранилище без изменений. Кеш заполняется чтением из основной
+// базы: отставшая реплика иначе попала бы в кеш как свежие данные на весь TTL.
+type Storage struct {
+	storage.Storage
+	cache   *lru
+	metrics Metrics
+	// writes упорядочивает изменения существующих событий: иначе два параллельных изменения
+	// одного события прочитали бы одну и ту же прежнюю версию и не убрали бы списки с промежуточной.
+	writes sync.Mutex
+}
+
+func New(s storage.Storage, metrics Metrics, conf config.CacheConf) *Storage {
+	return &Storage{Storage: s, cache: newLRU(conf.Size, conf.TTL), metrics: metrics}
+}
+
+func (s *Storage) Event() storage.EventRepository {
+	return &eventRepository{EventRepository: s.Storage.Event(), s: s}
+}
+
+func (s *Storage) Reminder() storage.ReminderRepository {
+	return &reminderRepository{ReminderRepository: s.Storage.Reminder(), s: s}
+}
+
+func (s *Storage) lookup(operation, key string) (any, bool) {
+	value, ok := s.cache.get(key)
+	s.metrics.ObserveCacheLookup(operation, ok)
+	return value, ok
+}
+
+type eventRepository struct {
+	storage.EventRepository
+	s *Storage
+}
+
+func (r *eventRepository) Get(ctx context.Context, id int) (domain.Event, error) {
+	key := eventKey(id)
+	if value, ok := r.s.lookup("event_get", key); ok {
+		return cloneEvent(value.(domain.Event)), nil //nolint:forcetypeassert
+	}
+
+	gen := r.s.cache.gen()
+	event, err := r.EventRepository.Get(storage.WithPrimary(ctx), id)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	r.s.cache.set(gen, &entry{key: key, value: cloneEvent(event)})
+	return event, nil
+}
+
+// Границы периодов совпадают с теми, по которым выбирают события хранилища.
+
+func (r *eventRepository) ListByDay(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.list(ctx, "event_list_by_day", userID, date, start, start.Add(24*time.Hour), tags,
+		r.EventRepository.ListByDay)
+}
+
+func (r *eventRepository) ListByWeek(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.list(ctx, "event_list_by_week", userID, date, start, start.Add(7*24*time.Hour), tags,
+		r.EventRepository.ListByWeek)
+}
+
+func (r *eventRepository) ListByMonth(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return r.list(ctx, "event_list_by_month", userID, date, start, start.AddDate(0, 1, 0), tags,
+		r.EventRepository.ListByMonth)
+}
+
+func (r *eventRepository) list(
+	ctx context.Context, operation string, userID int, date, start, end time.Time, tags domain.TagFilter,
+	load func(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error),
+) ([]domain.Event, error) {
+	// Дни событий на весь день считаются по календарю пояса start, поэтому в ключе
+	// границы записаны вместе со смещением.
+	key := "list:" + strconv.Itoa(userID) + ":" + start.Format(time.RFC3339Nano) + "/" + end.Format(time.RFC3339Nano)
+	if !tags.Empty() {
+		key += ":" + string(tags.Match) + ":" + strings.Join(tags.Tags, ",")
+	}
+	if value, ok := r.s.lookup(operation, key); ok {
+		return cloneEvents(value.([]domain.Event)), nil //nolint:forcetypeassert
+	}
+
+	gen := r.s.cache.gen()
+	events, err := load(storage.WithPrimary(ctx), userID, date, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.cache.set(gen, &entry{key: key, value: cloneEvents(events), isList: true, start: start, end: end})
+	return events, nil
+}
+
+func (r *eventRepository) Create(ctx context.Context, e *domain.Event) error {
+	err := r.EventRepository.Create(ctx, e)
+	r.s.cache.invalidate(nil, within(*e))
+	return err
+}
+
+func (r *eventRepository) Restore(ctx context.Context, e *domain.Event) error {
+	err := r.EventRepository.Restore(ctx, e)
+	r.s.cache.invalidate(nil, within(*e))
+	return err
+}
+
+func (r *eventRepository) Update(ctx context.Context, id int, e *domain.Event) error {
+	r.s.writes.Lock()
+	defer r.s.writes.Unlock()
+
+	previous := r.previous(ctx, id)
+	err := r.EventRepository.Update(ctx, id, e)
+	r.s.cache.invalidate([]int{id}, within(append(previous, *e)...))
+	return err
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id int) error {
+	r.s.writes.Lock()
+	defer r.s.writes.Unlock()
+
+	previous := r.previous(ctx, id)
+	err := r.EventRepository.Delete(ctx, id)
+	r.s.cache.invalidate([]int{id}, within(previous...))
+	return err
+}
+
+func (r *eventRepository) CreateBatch(ctx context.Context, events []*domain.Event) error {
+	err := r.EventRepository.CreateBatch(ctx, events)
+
+	created := make([]domain.Event, len(events))
+	for i, e := range events {
+		created[i] = *e
+	}
+	r.s.cache.invalidate(nil, within(created...))
+	return err
+}
+
+// UpdateBatch сбрасывает все списки: прежние версии событий пакета пришлось бы читать
+// из хранилища по одной, а это дороже, чем перечитать списки.
+func (r *eventRepository) UpdateBatch(
+	ctx context.Context, userID int, events []*domain.Event, mode domain.BatchMode,
+) ([]error, error) {
+	r.s.writes.Lock()
+	defer r.s.writes.Unlock()
+
+	errs, err := r.EventRepository.UpdateBatch(ctx, userID, events, mode)
+
+	ids := make([]int, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	r.s.cache.invalidate(ids, func(time.Time, time.Time) bool { return true })
+	return errs, err
+}
+
+func (r *eventRepository) DeleteBatch(
+	ctx context.Context, userID int, ids []int, mode domain.BatchMode,
+) ([]domain.Event, []error, error) {
+	r.s.writes.Lock()
+	defer r.s.writes.Unlock()
+
+	deleted, errs, err := r.EventRepository.DeleteBatch(ctx, userID, ids, mode)
+	r.s.cache.invalidate(ids, within(deleted...))
+	return deleted, errs, err
+}
+
+// previous возвращает текущую версию события, чтобы после изменения убрать списки,
+// в которые оно попадало. Отсутствующее событие ни в какие списки не попадает.
+func (r *eventRepository) previous(ctx context.Context, id int) []domain.Event {
+	if value, ok := r.s.cache.get(eventKey(id)); ok {
+		return []domain.Event{value.(domain.Event)} //nolint:forcetypeassert
+	}
+	event, err := r.EventRepository.Get(storage.WithPrimary(ctx), id)
+	if err != nil {
+		return nil
+	}
+	return []domain.Event{event}
+}
+
+// reminderRepository сбрасывает кеш целиком при отправке напоминания: статус напоминания
+// входит в закешированные события, а каких именно — по ID напоминания не узнать.
+type reminderRepository struct {
+	storage.ReminderRepository
+	s *Storage
+}
+
+func (r *reminderRepository) Fire(ctx context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage) error {
+	err := r.ReminderRepository.Fire(ctx, id, sentAt, msg)
+	r.s.cache.clear()
+	return err
+}
+
+func eventKey(id int) string {
+	return "event:" + strconv.Itoa(id)
+}
+
+func within(events ...domain.Event) func(start, end time.Time) bool {
+	return func(start, end time.Time) bool {
+		for i := range events {
+			if events[i].Within(start, end) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func cloneEvent(e domain.Event) domain.Event {
+	e.Reminders = slices.Clone(e.Reminders)
+	e.Tags = slices.Clone(e.Tags)
+	return e
+}
+
+func cloneEvents(events []domain.Event) []domain.Event {
+	if events == nil {
+		return nil
+	}
+	out := make([]domain.Event, len(events))
+	for i := range events {
+		out[i] = cloneEvent(events[i])
+	}
+	return out
+}
@@ -0,0 +1,292 @@
+package cachestorage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noTags — пустой фильтр тегов, пропускает все события.
+var noTags domain.TagFilter
+
+type lookups struct {
+	mu           sync.Mutex
+	hits, misses int
+}
+
+func (l *lookups) ObserveCacheLookup(_ string, hit bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if hit {
+		l.hits++
+	} else {
+		l.misses++
+	}
+}
+
+func (l *lookups) reset() (hits, misses int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hits, misses = l.hits, l.misses
+	l.hits, l.misses = 0, 0
+	return hits, misses
+}
+
+func newTestStorage(size int) (*Storage, *lookups) {
+	m := &lookups{}
+	return New(memorystorage.NewStorage(), m, config.CacheConf{Size: size, TTL: time.Minute}), m
+}
+
+// titlesOf возвращает функцию, которая принимает результат List* целиком.
+func titlesOf(t *testing.T) func(events []domain.Event, err error) []string {
+	t.Helper()
+	return func(events []domain.Event, err error) []string {
+		require.NoError(t, err)
+		out := make([]string, len(events))
+		for i, e := range events {
+			out[i] = e.Title
+		}
+		return out
+	}
+}
+
+func TestStorage_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	titles := titlesOf(t)
+	s, m := newTestStorage(100)
+	repo := s.Event()
+
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	standup := &domain.Event{Title: "Standup", EventTime: monday.Add(10 * time.Hour), Duration: time.Hour, UserID: 1}
+	require.NoError(t, repo.Create(ctx, standup))
+
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+	assert.Empty(t, titles(repo.ListByDay(ctx, 1, tuesday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+	_, err := repo.Get(ctx, standup.ID)
+	require.NoError(t, err)
+	hits, misses := m.reset()
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 3, misses)
+
+	// Событие переносится на вторник: устаревают оба дня, неделя и само событие.
+	_, _ = repo.ListByWeek(ctx, 1, monday, noTags)
+	moved := *standup
+	moved.EventTime = tuesday.Add(10 * time.Hour)
+	require.NoError(t, repo.Update(ctx, standup.ID, &moved))
+	m.reset()
+
+	assert.Empty(t, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, tuesday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByWeek(ctx, 1, monday, noTags)))
+	event, err := repo.Get(ctx, standup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, moved.EventTime, event.EventTime)
+	hits, _ = m.reset()
+	assert.Zero(t, hits)
+
+	// Событие в другом месяце не трогает закешированные списки ноября.
+	_, _ = repo.ListByMonth(ctx, 1, monday, noTags)
+	require.NoError(t, repo.Create(ctx, &domain.Event{
+		Title: "Retro", EventTime: monday.AddDate(0, 1, 0), Duration: time.Hour, UserID: 1,
+	}))
+	m.reset()
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByMonth(ctx, 1, monday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, tuesday, noTags)))
+	hits, misses = m.reset()
+	assert.Equal(t, 2, hits)
+	assert.Zero(t, misses)
+
+	require.NoError(t, repo.Delete(ctx, standup.ID))
+	assert.Empty(t, titles(repo.ListByMonth(ctx, 1, monday, noTags)))
+	_, err = repo.Get(ctx, standup.ID)
+	assert.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// Событие на несколько дней убирает списки каждого своего дня.
+	_, _ = repo.ListByDay(ctx, 1, tuesday.AddDate(0, 0, 1), noTags)
+	vacation := &domain.Event{Title: "Vacation", UserID: 1}
+	require.NoError(t, vacation.SetDates(monday, tuesday.AddDate(0, 0, 2)))
+	require.NoError(t, repo.CreateBatch(ctx, []*domain.Event{vacation}))
+	assert.Equal(t, []string{"Vacation"}, titles(repo.ListByDay(ctx, 1, tuesday.AddDate(0, 0, 1), noTags)))
+}
+
+func TestStorage_ListsPerUser(t *testing.T) {
+	ctx := context.Background()
+	titles := titlesOf(t)
+	s, _ := newTestStorage(100)
+	repo := s.Event()
+
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	for _, e := range []*domain.Event{
+		{Title: "Standup", EventTime: monday.Add(10 * time.Hour), Duration: time.Hour, UserID: 1, Tags: []string{"team"}},
+		{Title: "Dentist", EventTime: monday.Add(12 * time.Hour), Duration: time.Hour, UserID: 1},
+		{Title: "Review", EventTime: monday.Add(14 * time.Hour), Duration: time.Hour, UserID: 2, Tags: []string{"team"}},
+	} {
+		require.NoError(t, repo.Create(ctx, e))
+	}
+
+	// Закешированный список одного пользователя или фильтра не отдаётся другому.
+	team, err := domain.NewTagFilter([]string{"team"}, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Standup", "Dentist"}, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, monday, team)))
+	assert.Equal(t, []string{"Review"}, titles(repo.ListByDay(ctx, 2, monday, noTags)))
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, monday, team)))
+}
+
+// laggingStorage пишет в основное хранилище, а без storage.WithPrimary читает из replica,
+// куда изменения не доходят, — как реплика с большим отставанием.
+type laggingStorage struct {
+	storage.Storage
+	replica storage.Storage
+}
+
+func (s *laggingStorage) Event() storage.EventRepository {
+	return &laggingEvents{EventRepository: s.Storage.Event(), replica: s.replica.Event()}
+}
+
+type laggingEvents struct {
+	storage.EventRepository
+	replica storage.EventRepository
+}
+
+func (r *laggingEvents) source(ctx context.Context) storage.EventRepository {
+	if storage.PrimaryRequired(ctx) {
+		return r.EventRepository
+	}
+	return r.replica
+}
+
+func (r *laggingEvents) Get(ctx context.Context, id int) (domain.Event, error) {
+	return r.source(ctx).Get(ctx, id)
+}
+
+func (r *laggingEvents) ListByDay(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	return r.source(ctx).ListByDay(ctx, userID, date, tags)
+}
+
+func TestStorage_FillsFromPrimary(t *testing.T) {
+	ctx := context.Background()
+	titles := titlesOf(t)
+	primary := memorystorage.NewStorage()
+	s := New(&laggingStorage{Storage: primary, replica: memorystorage.NewStorage()}, &lookups{},
+		config.CacheConf{Size: 100, TTL: time.Minute})
+	repo := s.Event()
+
+	monday := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	// Событие создано в обход кеша, например другим экземпляром сервиса.
+	standup := &domain.Event{Title: "Standup", EventTime: monday.Add(10 * time.Hour), Duration: time.Hour, UserID: 1}
+	require.NoError(t, primary.Event().Create(ctx, standup))
+
+	assert.Equal(t, []string{"Standup"}, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+
+	// Прежняя версия для сброса списков тоже читается из основного хранилища.
+	moved := *standup
+	moved.EventTime = tuesday.Add(10 * time.Hour)
+	require.NoError(t, repo.Update(ctx, standup.ID, &moved))
+	assert.Empty(t, titles(repo.ListByDay(ctx, 1, monday, noTags)))
+
+	event, err := repo.Get(ctx, standup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, moved.EventTime, event.EventTime)
+}
+
+func TestStorage_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStorage(100)
+	repo := s.Event()
+
+	event := &domain.Event{
+		Title: "Sync", EventTime: time.Date(2025, 11, 3, 10, 0, 0, 0, time.UTC), Duration: time.Hour, Tags: []string{"team"},
+	}
+	require.NoError(t, repo.Create(ctx, event))
+
+	first, err := repo.Get(ctx, event.ID)
+	require.NoError(t, err)
+	first.Tags[0] = "changed"
+
+	second, err := repo.Get(ctx, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team"}, second.Tags)
+}
+
+func TestLRU_EvictionAndTTL(t *testing.T) {
+	now := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	c := newLRU(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set(c.gen(), &entry{key: "a", value: 1})
+	c.set(c.gen(), &entry{key: "b", value: 2})
+	_, ok := c.get("a")
+	require.True(t, ok)
+
+	// Вытесняется давно не использованная запись.
+	c.set(c.gen(), &entry{key: "c", value: 3})
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+
+	// Значение, прочитанное до инвалидации, в кеш не попадает.
+	gen := c.gen()
+	c.invalidate(nil, func(time.Time, time.Time) bool { return false })
+	c.set(gen, &entry{key: "stale", value: 0})
+	_, ok = c.get("stale")
+	assert.False(t, ok)
+}
+
+func TestStorage_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	titles := titlesOf(t)
+	s, _ := newTestStorage(8)
+	repo := s.Event()
+	day := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			event := &domain.Event{
+				Title: "Event", EventTime: day.Add(time.Duration(i) * time.Hour), Duration: time.Hour, UserID: 1,
+			}
+			_ = repo.Create(ctx, event)
+			event.EventTime = event.EventTime.AddDate(0, 0, 1)
+			_ = repo.Update(ctx, event.ID, event)
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, _ = repo.ListByDay(ctx, 1, day.AddDate(0, 0, j%2), noTags)
+				_, _ = repo.ListByWeek(ctx, 1, day, noTags)
+				_, _ = repo.Get(ctx, j%10+1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, titles(repo.ListByDay(ctx, 1, day, noTags)))
+	assert.Len(t, titles(repo.ListByDay(ctx, 1, day.AddDate(0, 0, 1), noTags)), 10)
+	assert.Len(t, titles(repo.ListByWeek(ctx, 1, day, noTags)), 10)
+	assert.LessOrEqual(t, s.cache.len(), 8)
+}
@@ -0,0 +1,42 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type ChannelRepository struct {
+	storage *Storage
+}
+
+func (r *ChannelRepository) Get(_ context.Context, userID int) (domain.NotificationChannel, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	channel, exists := r.storage.channels[userID]
+	if !exists {
+		return domain.NotificationChannel{}, domain.ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+func (r *ChannelRepository) Set(_ context.Context, c *domain.NotificationChannel) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.channels[c.UserID] = *c
+	return nil
+}
+
+func (r *ChannelRepository) Delete(_ context.Context, userID int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, exists := r.storage.channels[userID]; !exists {
+		return domain.ErrChannelNotFound
+	}
+
+	delete(r.storage.channels, userID)
+	return nil
+}
@@ -1,6 +1,8 @@
 package memorystorage
 
 import (
+	"context"
+	"sort"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
@@ -10,42 +12,70 @@ type EventRepository struct {
 	storage *Storage
 }
 
-func (r *EventRepository) Create(e *domain.Event) error {
+func (r *EventRepository) Create(_ context.Context, e *domain.Event) error {
 	r.storage.mu.Lock()
 	defer r.storage.mu.Unlock()
 
 	e.ID = r.storage.nextID
-	r.storage.events[e.ID] = e
+	e.ScheduleReminders(nil)
+	r.storage.assignReminderIDs(e)
+	r.storage.indexEvent(e)
+	r.storage.events[e.ID] = cloneEvent(e)
 	r.storage.nextID++
 	return nil
 }
 
-func (r *EventRepository) Update(id int, e *domain.Event) error {
+func (r *EventRepository) Restore(_ context.Context, e *domain.Event) error {
 	r.storage.mu.Lock()
 	defer r.storage.mu.Unlock()
 
-	if _, exists := r.storage.events[id]; !exists {
+	if _, taken := r.storage.events[e.ID]; taken || e.ID <= 0 {
+		if e.ID > 0 {
+			r.storage.restoredFrom[r.storage.nextID] = e.ID
+		}
+		e.ID = r.storage.nextID
+	}
+	r.storage.nextID = max(r.storage.nextID, e.ID+1)
+
+	e.RestoreReminders()
+	r.storage.assignReminderIDs(e)
+	r.storage.indexEvent(e)
+	r.storage.events[e.ID] = cloneEvent(e)
+	return nil
+}
+
+func (r *EventRepository) Update(_ context.Context, id int, e *domain.Event) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	prev, exists := r.storage.events[id]
+	if !exists {
 		return domain.ErrEventNotFound
 	}
 
 	e.ID = id
-	r.storage.events[id] = e
+	e.ScheduleReminders(prev.Reminders)
+	r.storage.assignReminderIDs(e)
+	r.storage.indexEvent(e)
+	r.storage.events[id] = cloneEvent(e)
 	return nil
 }
 
-func (r *EventRepository) Delete(id int) error {
+func (r *EventRepository) Delete(_ context.Context, id int) error {
 	r.storage.mu.Lock()
 	defer r.storage.mu.Unlock()
 
-	if _, exists := r.storage.events[id]; !exists {
+	event, exists := r.storage.events[id]
+	if !exists {
 		return domain.ErrEventNotFound
 	}
 
+	r.storage.index.remove(event)
 	delete(r.storage.events, id)
 	return nil
 }
 
-func (r *EventRepository) Get(id int) (domain.Event, error) {
+func (r *EventRepository) Get(_ context.Context, id int) (domain.Event, error) {
 	r.storage.mu.RLock()
 	defer r.storage.mu.RUnlock()
 
@@ -53,10 +83,12 @@ func (r *EventRepository) Get(id int) (domain.Event, error) {
 	if !exists {
 		return domain.Event{}, domain.ErrEventNotFound
 	}
-	return *event, nil
+	return *cloneEvent(event), nil
 }
 
-func (r *EventRepository) ListByDay(date time.Time) ([]domain.Event, error) {
+func (r *EventRepository) ListByDay(
+	_ context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
 	r.storage.mu.RLock()
 	defer r.storage.mu.RUnlock()
 
@@ -65,15 +97,16 @@ func (r *EventRepository) ListByDay(date time.Time) ([]domain.Event, error) {
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
 	for _, event := range r.storage.events {
-		if (event.EventTime.After(startOfDay) || event.EventTime.Equal(startOfDay)) &&
-			event.EventTime.Before(endOfDay) {
-			events = append(events, *event)
+		if event.UserID == userID && event.Within(startOfDay, endOfDay) && tags.Matches(event.Tags) {
+			events = append(events, *cloneEvent(event))
 		}
 	}
 	return events, nil
 }
 
-func (r *EventRepository) ListByWeek(date time.Time) ([]domain.Event, error) {
+func (r *EventRepository) ListByWeek(
+	_ context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
 	r.storage.mu.RLock()
 	defer r.storage.mu.RUnlock()
 
@@ -82,15 +115,16 @@ func (r *EventRepository) ListByWeek(date time.Time) ([]domain.Event, error) {
 	endOfWeek := startOfWeek.Add(7 * 24 * time.Hour)
 
 	for _, event := range r.storage.events {
-		if (event.EventTime.After(startOfWeek) || event.EventTime.Equal(startOfWeek)) &&
-			event.EventTime.Before(endOfWeek) {
-			events = append(events, *event)
+		if event.UserID == userID && event.Within(startOfWeek, endOfWeek) && tags.Matches(event.Tags) {
+			events = append(events, *cloneEvent(event))
 		}
 	}
 	return events, nil
 }
 
-func (r *EventRepository) ListByMonth(date time.Time) ([]domain.Event, error) {
+func (r *EventRepository) ListByMonth(
+	_ context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
 	r.storage.mu.RLock()
 	defer r.storage.mu.RUnlock()
 
@@ -99,10 +133,186 @@ func (r *EventRepository) ListByMonth(date time.Time) ([]domain.Event, error) {
 	startOfNextMonth := startOfMonth.AddDate(0, 1, 0)
 
 	for _, event := range r.storage.events {
-		if (event.EventTime.After(startOfMonth) || event.EventTime.Equal(startOfMonth)) &&
-			event.EventTime.Before(startOfNextMonth) {
-			events = append(events, *event)
+		if event.UserID == userID && event.Within(startOfMonth, startOfNextMonth) && tags.Matches(event.Tags) {
+			events = append(events, *cloneEvent(event))
+		}
+	}
+	return events, nil
+}
+
+func (r *EventRepository) ListAfter(_ context.Context, userID, afterID, limit int) ([]domain.Event, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	ids := make([]int, 0, len(r.storage.events))
+	for id, event := range r.storage.events {
+		if id > afterID && (userID == 0 || event.UserID == userID) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	events := make([]domain.Event, len(ids))
+	for i, id := range ids {
+		events[i] = *cloneEvent(r.storage.events[id])
+	}
+	return events, nil
+}
+
+func (r *EventRepository) ListRestored(_ context.Context, sourceID int) ([]domain.Event, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	// ID событий не переиспользуются, поэтому записи удалённых событий просто пропускаются.
+	ids := make([]int, 0)
+	for id, from := range r.storage.restoredFrom {
+		if _, exists := r.storage.events[id]; exists && from == sourceID {
+			ids = append(ids, id)
 		}
 	}
+	sort.Ints(ids)
+
+	events := make([]domain.Event, len(ids))
+	for i, id := range ids {
+		events[i] = *cloneEvent(r.storage.events[id])
+	}
 	return events, nil
 }
+
+func (r *EventRepository) Count(_ context.Context) (int, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	return len(r.storage.events), nil
+}
+
+func (r *EventRepository) CreateBatch(_ context.Context, events []*domain.Event) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, e := range events {
+		e.ID = r.storage.nextID
+		e.ScheduleReminders(nil)
+		r.storage.assignReminderIDs(e)
+		r.storage.indexEvent(e)
+		r.storage.events[e.ID] = cloneEvent(e)
+		r.storage.nextID++
+	}
+	return nil
+}
+
+func (r *EventRepository) UpdateBatch(
+	_ context.Context, userID int, events []*domain.Event, mode domain.BatchMode,
+) ([]error, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	errs := make([]error, len(events))
+	for i, e := range events {
+		if prev, exists := r.storage.events[e.ID]; !exists || prev.UserID != userID {
+			errs[i] = domain.ErrEventNotFound
+		}
+	}
+	if mode == domain.BatchAllOrNothing && domain.BatchFailed(errs) {
+		domain.AbortBatch(errs)
+		return errs, nil
+	}
+
+	for i, e := range events {
+		if errs[i] != nil {
+			continue
+		}
+		e.UserID = userID
+		e.ScheduleReminders(r.storage.events[e.ID].Reminders)
+		r.storage.assignReminderIDs(e)
+		r.storage.indexEvent(e)
+		r.storage.events[e.ID] = cloneEvent(e)
+	}
+	return errs, nil
+}
+
+func (r *EventRepository) DeleteBatch(
+	_ context.Context, userID int, ids []int, mode domain.BatchMode,
+) ([]domain.Event, []error, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	deleted := make([]domain.Event, len(ids))
+	errs := make([]error, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for i, id := range ids {
+		event, exists := r.storage.events[id]
+		if !exists || event.UserID != userID || seen[id] {
+			errs[i] = domain.ErrEventNotFound
+			continue
+		}
+		seen[id] = true
+		deleted[i] = *cloneEvent(event)
+	}
+	if mode == domain.BatchAllOrNothing && domain.BatchFailed(errs) {
+		domain.AbortBatch(errs)
+		return make([]domain.Event, len(ids)), errs, nil
+	}
+
+	for i, id := range ids {
+		if errs[i] == nil {
+			r.storage.index.remove(r.storage.events[id])
+			delete(r.storage.events, id)
+		}
+	}
+	return deleted, errs, nil
+}
+
+func (r *EventRepository) TagCounts(_ context.Context, userID int) ([]domain.TagCount, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, event := range r.storage.events {
+		if event.UserID == userID {
+			for _, tag := range event.Tags {
+				counts[tag]++
+			}
+		}
+	}
+	return sortTagCounts(counts), nil
+}
+
+func sortTagCounts(counts map[string]int) []domain.TagCount {
+	result := make([]domain.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, domain.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+func (s *Storage) assignReminderIDs(e *domain.Event) {
+	for i := range e.Reminders {
+		if e.Reminders[i].ID == 0 {
+			e.Reminders[i].ID = s.nextRemID
+			s.nextRemID++
+		}
+	}
+}
+
+// cloneEvent копирует событие вместе со слайсами напоминаний и тегов,
+// чтобы вызывающий код не мог изменить данные хранилища.
+func cloneEvent(e *domain.Event) *domain.Event {
+	clone := *e
+	if e.Reminders != nil {
+		clone.Reminders = append([]domain.Reminder(nil), e.Reminders...)
+	}
+	if e.Tags != nil {
+		clone.Tags = append([]string(nil), e.Tags...)
+	}
+	return &clone
+}
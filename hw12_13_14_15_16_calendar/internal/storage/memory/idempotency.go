@@ -0,0 +1,75 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type idempotencyKey struct {
+	userID int
+	key    string
+}
+
+type IdempotencyRepository struct {
+	storage *Storage
+}
+
+func (r *IdempotencyRepository) Begin(
+	_ context.Context, rec domain.IdempotencyRecord,
+) (domain.IdempotencyRecord, bool, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	k := idempotencyKey{userID: rec.UserID, key: rec.Key}
+	if existing, exists := r.storage.idemKeys[k]; exists && existing.ExpiresAt.After(rec.CreatedAt) {
+		return existing, false, nil
+	}
+
+	r.storage.idemKeys[k] = rec
+	return rec, true, nil
+}
+
+func (r *IdempotencyRepository) Complete(
+	_ context.Context, userID int, key, owner string, response []byte, expiresAt time.Time,
+) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	k := idempotencyKey{userID: userID, key: key}
+	rec, exists := r.storage.idemKeys[k]
+	if !exists || rec.Owner != owner {
+		return domain.ErrIdempotencyKeyNotFound
+	}
+
+	rec.Response = append([]byte{}, response...)
+	rec.ExpiresAt = expiresAt
+	r.storage.idemKeys[k] = rec
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(_ context.Context, userID int, key, owner string) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	k := idempotencyKey{userID: userID, key: key}
+	if rec, exists := r.storage.idemKeys[k]; exists && rec.Owner == owner {
+		delete(r.storage.idemKeys, k)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	var n int
+	for k, rec := range r.storage.idemKeys {
+		if !rec.ExpiresAt.After(now) {
+			delete(r.storage.idemKeys, k)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,100 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type OutboxRepository struct {
+	storage *Storage
+}
+
+func (r *OutboxRepository) ClaimPending(
+	_ context.Context, owner string, now, until time.Time, limit int,
+) ([]domain.OutboxMessage, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	var messages []domain.OutboxMessage
+	for i := range r.storage.outbox {
+		if len(messages) == limit {
+			break
+		}
+		msg := &r.storage.outbox[i]
+		if msg.Claimable(owner, now) {
+			msg.ClaimedBy, msg.ClaimedUntil = owner, until
+			messages = append(messages, cloneOutboxMessage(*msg))
+		}
+	}
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkDispatched(_ context.Context, ids []int, dispatchedAt time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	marked := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		marked[id] = true
+	}
+	for i := range r.storage.outbox {
+		if marked[r.storage.outbox[i].ID] && !r.storage.outbox[i].Dispatched() {
+			r.storage.outbox[i].DispatchedAt = dispatchedAt
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRepository) MarkProcessed(_ context.Context, id int, processedAt time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for i := range r.storage.outbox {
+		if r.storage.outbox[i].ID == id && r.storage.outbox[i].ProcessedAt.IsZero() {
+			r.storage.outbox[i].ProcessedAt = processedAt
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRepository) Processed(_ context.Context, id int) (bool, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, msg := range r.storage.outbox {
+		if msg.ID == id {
+			return !msg.ProcessedAt.IsZero(), nil
+		}
+	}
+	return false, nil
+}
+
+func (r *OutboxRepository) DeleteDispatched(_ context.Context, before time.Time) (int, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	kept := r.storage.outbox[:0]
+	for _, msg := range r.storage.outbox {
+		if !msg.Dispatched() || !msg.DispatchedAt.Before(before) {
+			kept = append(kept, msg)
+		}
+	}
+	deleted := len(r.storage.outbox) - len(kept)
+	clear(r.storage.outbox[len(kept):])
+	r.storage.outbox = kept
+	return deleted, nil
+}
+
+// addOutboxMessage вызывается под мьютексом.
+func (s *Storage) addOutboxMessage(msg *domain.OutboxMessage) {
+	msg.ID = s.nextOutboxID
+	s.nextOutboxID++
+	s.outbox = append(s.outbox, cloneOutboxMessage(*msg))
+}
+
+func cloneOutboxMessage(msg domain.OutboxMessage) domain.OutboxMessage {
+	msg.Payload = append([]byte(nil), msg.Payload...)
+	return msg
+}
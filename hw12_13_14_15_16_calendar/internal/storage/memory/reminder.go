@@ -0,0 +1,52 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type ReminderRepository struct {
+	storage *Storage
+}
+
+func (r *ReminderRepository) ListDue(_ context.Context, now time.Time) ([]domain.Reminder, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var reminders []domain.Reminder
+	for _, event := range r.storage.events {
+		for _, reminder := range event.Reminders {
+			if reminder.Status == domain.ReminderPending && !reminder.FireAt.After(now) {
+				reminders = append(reminders, reminder)
+			}
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].FireAt.Before(reminders[j].FireAt) })
+	return reminders, nil
+}
+
+func (r *ReminderRepository) Fire(_ context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, event := range r.storage.events {
+		for i := range event.Reminders {
+			if event.Reminders[i].ID != id {
+				continue
+			}
+			if event.Reminders[i].Status == domain.ReminderSent {
+				return domain.ErrReminderAlreadySent
+			}
+			event.Reminders[i].Status = domain.ReminderSent
+			event.Reminders[i].SentAt = sentAt
+			r.storage.addOutboxMessage(msg)
+			return nil
+		}
+	}
+
+	return domain.ErrReminderNotFound
+}
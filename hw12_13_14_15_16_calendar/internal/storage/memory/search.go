@@ -0,0 +1,199 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+// Веса совпадений в названии и описании, как у setweight 'A' и 'B' в Postgres.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+// searchIndex — инвертированный индекс: слово названия или описания → ID событий.
+type searchIndex map[string]map[int]struct{}
+
+func (idx searchIndex) add(e *domain.Event) {
+	for _, token := range eventTokens(e) {
+		ids, ok := idx[token]
+		if !ok {
+			ids = make(map[int]struct{})
+			idx[token] = ids
+		}
+		ids[e.ID] = struct{}{}
+	}
+}
+
+func (idx searchIndex) remove(e *domain.Event) {
+	for _, token := range eventTokens(e) {
+		delete(idx[token], e.ID)
+		if len(idx[token]) == 0 {
+			delete(idx, token)
+		}
+	}
+}
+
+// match возвращает события со словом, которое начинается с prefix.
+func (idx searchIndex) match(prefix string) map[int]struct{} {
+	ids := make(map[int]struct{})
+	for token, postings := range idx {
+		if strings.HasPrefix(token, prefix) {
+			for id := range postings {
+				ids[id] = struct{}{}
+			}
+		}
+	}
+	return ids
+}
+
+func eventTokens(e *domain.Event) []string {
+	return domain.Tokenize(e.Title + " " + e.Description)
+}
+
+// indexEvent заменяет в индексе прежнюю версию события новой; вызывается под мьютексом.
+func (s *Storage) indexEvent(e *domain.Event) {
+	if prev, exists := s.events[e.ID]; exists {
+		s.index.remove(prev)
+	}
+	s.index.add(e)
+}
+
+func (r *EventRepository) Search(_ context.Context, q domain.SearchQuery) ([]domain.SearchResult, error) {
+	terms := domain.Tokenize(q.Text)
+
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var candidates map[int]struct{}
+	for _, term := range terms {
+		ids := r.storage.index.match(term)
+		if candidates == nil {
+			candidates = ids
+			continue
+		}
+		for id := range candidates {
+			if _, ok := ids[id]; !ok {
+				delete(candidates, id)
+			}
+		}
+	}
+
+	results := make([]domain.SearchResult, 0, len(candidates))
+	for id := range candidates {
+		event := r.storage.events[id]
+		if (q.UserID != 0 && event.UserID != q.UserID) ||
+			(!q.From.IsZero() && event.EventTime.Before(q.From)) ||
+			(!q.To.IsZero() && !event.EventTime.Before(q.To)) ||
+			!q.Tags.Matches(event.Tags) {
+			continue
+		}
+
+		results = append(results, domain.SearchResult{
+			Event:   *cloneEvent(event),
+			Rank:    rank(event, terms),
+			Snippet: snippet(event, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Event.EventTime.Before(results[j].Event.EventTime)
+	})
+
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// rank считает взвешенное число слов события, совпавших со словами запроса.
+func rank(e *domain.Event, terms []string) float64 {
+	count := func(text string) float64 {
+		var n float64
+		for _, word := range words(text) {
+			if matchesAny(strings.ToLower(text[word[0]:word[1]]), terms) {
+				n++
+			}
+		}
+		return n
+	}
+	return titleWeight*count(e.Title) + descriptionWeight*count(e.Description)
+}
+
+// snippet подсвечивает совпадения в описании, а если их там нет — в названии,
+// и обрезает текст до domain.SnippetMaxWords слов начиная с первого совпадения.
+func snippet(e *domain.Event, terms []string) string {
+	text := e.Description
+	spans := words(text)
+	first := firstMatch(text, spans, terms)
+	if first < 0 {
+		text = e.Title
+		spans = words(text)
+		first = max(firstMatch(text, spans, terms), 0)
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+
+	// Немного контекста перед первым совпадением.
+	start := max(first-domain.SnippetMaxWords/4, 0)
+	end := min(start+domain.SnippetMaxWords, len(spans))
+
+	var b strings.Builder
+	pos := spans[start][0]
+	for _, word := range spans[start:end] {
+		b.WriteString(text[pos:word[0]])
+		if matchesAny(strings.ToLower(text[word[0]:word[1]]), terms) {
+			b.WriteString(domain.HighlightStart + text[word[0]:word[1]] + domain.HighlightStop)
+		} else {
+			b.WriteString(text[word[0]:word[1]])
+		}
+		pos = word[1]
+	}
+	return b.String()
+}
+
+func firstMatch(text string, spans [][2]int, terms []string) int {
+	for i, word := range spans {
+		if matchesAny(strings.ToLower(text[word[0]:word[1]]), terms) {
+			return i
+		}
+	}
+	return -1
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// words возвращает границы слов текста по тем же правилам, что и domain.Tokenize.
+func words(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
@@ -0,0 +1,110 @@
+package memorystorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Search(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	eventRepo := storage.Event()
+
+	day := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	events := []*domain.Event{
+		{Title: "Budget review", Description: "Quarterly budgets and forecasts", EventTime: day, UserID: 1},
+		{Title: "Standup", Description: "Mention the budget briefly", EventTime: day.Add(time.Hour), UserID: 1},
+		{Title: "Budget sync", EventTime: day.AddDate(0, 1, 0), UserID: 1},
+		{Title: "Budget", Description: "Someone else's budget", EventTime: day, UserID: 2},
+		{Title: "Lunch", EventTime: day, UserID: 1},
+	}
+	for _, e := range events {
+		e.Duration = time.Hour
+		require.NoError(t, eventRepo.Create(ctx, e))
+	}
+
+	results, err := eventRepo.Search(ctx, domain.SearchQuery{Text: "BUDGET", UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	// Совпадения в названии и описании весят больше, чем только в описании.
+	assert.Equal(t, "Budget review", results[0].Event.Title)
+	assert.Equal(t, "Quarterly <mark>budgets</mark> and forecasts", results[0].Snippet)
+	assert.Equal(t, "<mark>Budget</mark> sync", results[1].Snippet)
+	assert.Equal(t, "Standup", results[2].Event.Title)
+
+	results, err = eventRepo.Search(ctx, domain.SearchQuery{
+		Text:   "budget forecast",
+		UserID: 1,
+		From:   day,
+		To:     day.AddDate(0, 0, 7),
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, events[0].ID, results[0].Event.ID)
+
+	// Индекс следует за изменениями и удалением событий.
+	renamed := *events[4]
+	renamed.Title = "Budget lunch"
+	require.NoError(t, eventRepo.Update(ctx, renamed.ID, &renamed))
+	require.NoError(t, eventRepo.Delete(ctx, events[0].ID))
+
+	results, err = eventRepo.Search(ctx, domain.SearchQuery{Text: "budget", UserID: 1, Limit: 10})
+	require.NoError(t, err)
+	titles := make([]string, len(results))
+	for i, result := range results {
+		titles[i] = result.Event.Title
+	}
+	assert.ElementsMatch(t, []string{"Standup", "Budget sync", "Budget lunch"}, titles)
+
+	results, err = eventRepo.Search(ctx, domain.SearchQuery{Text: "review", UserID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestStorage_Tags(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	eventRepo := storage.Event()
+
+	day := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	for _, e := range []*domain.Event{
+		{Title: "Night shift", Tags: []string{"on-call"}, UserID: 1},
+		{Title: "Weekend shift", Tags: []string{"on-call", "weekend"}, UserID: 1},
+		{Title: "Shift review", Tags: []string{"1:1"}, UserID: 1},
+		{Title: "Shift", Tags: []string{"on-call"}, UserID: 2},
+	} {
+		e.EventTime, e.Duration = day, time.Hour
+		require.NoError(t, eventRepo.Create(ctx, e))
+	}
+
+	counts, err := eventRepo.TagCounts(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TagCount{
+		{Tag: "on-call", Count: 2}, {Tag: "1:1", Count: 1}, {Tag: "weekend", Count: 1},
+	}, counts)
+
+	search := func(match domain.TagMatch, tags ...string) []string {
+		results, err := eventRepo.Search(ctx, domain.SearchQuery{
+			Text:   "shift",
+			UserID: 1,
+			Tags:   domain.TagFilter{Tags: tags, Match: match},
+		})
+		require.NoError(t, err)
+
+		titles := make([]string, len(results))
+		for i, result := range results {
+			titles[i] = result.Event.Title
+		}
+		return titles
+	}
+
+	assert.ElementsMatch(t, []string{"Weekend shift"}, search(domain.TagMatchAll, "on-call", "weekend"))
+	assert.ElementsMatch(t, []string{"Night shift", "Weekend shift", "Shift review"},
+		search(domain.TagMatchAny, "on-call", "1:1"))
+	assert.Len(t, search(domain.TagMatchAll), 3)
+}
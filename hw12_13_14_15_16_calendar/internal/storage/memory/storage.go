@@ -8,15 +8,35 @@ import (
 )
 
 type Storage struct {
-	events map[int]*domain.Event
-	mu     sync.RWMutex
-	nextID int
+	events       map[int]*domain.Event
+	restoredFrom map[int]int // новый ID события → его ID в резервной копии
+	index        searchIndex
+	webhooks     map[int]*domain.Webhook
+	deliveries   []domain.WebhookDelivery
+	channels     map[int]domain.NotificationChannel
+	idemKeys     map[idempotencyKey]domain.IdempotencyRecord
+	outbox       []domain.OutboxMessage
+	mu           sync.RWMutex
+	nextID       int
+	nextHookID   int
+	nextDelID    int
+	nextRemID    int
+	nextOutboxID int
 }
 
 func NewStorage() *Storage {
 	return &Storage{
-		events: make(map[int]*domain.Event),
-		nextID: 1,
+		events:       make(map[int]*domain.Event),
+		restoredFrom: make(map[int]int),
+		index:        make(searchIndex),
+		webhooks:     make(map[int]*domain.Webhook),
+		channels:     make(map[int]domain.NotificationChannel),
+		idemKeys:     make(map[idempotencyKey]domain.IdempotencyRecord),
+		nextID:       1,
+		nextHookID:   1,
+		nextDelID:    1,
+		nextRemID:    1,
+		nextOutboxID: 1,
 	}
 }
 
@@ -25,3 +45,33 @@ func (s *Storage) Event() storage.EventRepository {
 		storage: s,
 	}
 }
+
+func (s *Storage) Webhook() storage.WebhookRepository {
+	return &WebhookRepository{
+		storage: s,
+	}
+}
+
+func (s *Storage) Channel() storage.ChannelRepository {
+	return &ChannelRepository{
+		storage: s,
+	}
+}
+
+func (s *Storage) Reminder() storage.ReminderRepository {
+	return &ReminderRepository{
+		storage: s,
+	}
+}
+
+func (s *Storage) Idempotency() storage.IdempotencyRepository {
+	return &IdempotencyRepository{
+		storage: s,
+	}
+}
+
+func (s *Storage) Outbox() storage.OutboxRepository {
+	return &OutboxRepository{
+		storage: s,
+	}
+}
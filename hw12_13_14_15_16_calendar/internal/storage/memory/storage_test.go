@@ -1,6 +1,8 @@
 package memorystorage
 
 import (
+	"context"
+	"sort"
 	"testing"
 	"time"
 
@@ -9,7 +11,11 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// noTags — пустой фильтр тегов, пропускает все события.
+var noTags domain.TagFilter
+
 func TestStorage_CRUD(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
@@ -20,11 +26,11 @@ func TestStorage_CRUD(t *testing.T) {
 		UserID:    1,
 	}
 
-	err := eventRepo.Create(event)
+	err := eventRepo.Create(ctx, event)
 	require.NoError(t, err)
 	assert.Equal(t, 1, event.ID)
 
-	retrieved, err := eventRepo.Get(event.ID)
+	retrieved, err := eventRepo.Get(ctx, event.ID)
 	require.NoError(t, err)
 	assert.Equal(t, event.Title, retrieved.Title)
 
@@ -35,29 +41,31 @@ func TestStorage_CRUD(t *testing.T) {
 		UserID:    2,
 	}
 
-	err = eventRepo.Update(event.ID, updatedEvent)
+	err = eventRepo.Update(ctx, event.ID, updatedEvent)
 	require.NoError(t, err)
 
-	updated, err := eventRepo.Get(event.ID)
+	updated, err := eventRepo.Get(ctx, event.ID)
 	require.NoError(t, err)
 	assert.Equal(t, "Updated Event", updated.Title)
 
-	err = eventRepo.Delete(event.ID)
+	err = eventRepo.Delete(ctx, event.ID)
 	require.NoError(t, err)
 
-	_, err = eventRepo.Get(event.ID)
+	_, err = eventRepo.Get(ctx, event.ID)
 	assert.ErrorIs(t, err, domain.ErrEventNotFound)
 }
 
 func TestStorage_GetNotFound(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
-	_, err := eventRepo.Get(999)
+	_, err := eventRepo.Get(ctx, 999)
 	assert.ErrorIs(t, err, domain.ErrEventNotFound)
 }
 
 func TestStorage_UpdateNotFound(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
@@ -68,19 +76,21 @@ func TestStorage_UpdateNotFound(t *testing.T) {
 		UserID:    1,
 	}
 
-	err := eventRepo.Update(999, event)
+	err := eventRepo.Update(ctx, 999, event)
 	assert.ErrorIs(t, err, domain.ErrEventNotFound)
 }
 
 func TestStorage_DeleteNotFound(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
-	err := eventRepo.Delete(999)
+	err := eventRepo.Delete(ctx, 999)
 	assert.ErrorIs(t, err, domain.ErrEventNotFound)
 }
 
 func TestStorage_ListByPeriods(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
@@ -98,34 +108,52 @@ func TestStorage_ListByPeriods(t *testing.T) {
 		Title:     "Today Evening",
 		EventTime: today.Add(18 * time.Hour),
 		Duration:  2 * time.Hour,
-		UserID:    2,
+		UserID:    1,
+		Tags:      []string{"home"},
 	}
 
 	event3 := &domain.Event{
 		Title:     "Tomorrow",
 		EventTime: today.AddDate(0, 0, 1).Add(10 * time.Hour),
 		Duration:  1 * time.Hour,
-		UserID:    3,
+		UserID:    1,
 	}
 
-	require.NoError(t, eventRepo.Create(event1))
-	require.NoError(t, eventRepo.Create(event2))
-	require.NoError(t, eventRepo.Create(event3))
+	foreign := &domain.Event{
+		Title:     "Foreign",
+		EventTime: today.Add(12 * time.Hour),
+		Duration:  1 * time.Hour,
+		UserID:    2,
+		Tags:      []string{"home"},
+	}
+
+	require.NoError(t, eventRepo.Create(ctx, event1))
+	require.NoError(t, eventRepo.Create(ctx, event2))
+	require.NoError(t, eventRepo.Create(ctx, event3))
+	require.NoError(t, eventRepo.Create(ctx, foreign))
 
-	dayEvents, err := eventRepo.ListByDay(today)
+	dayEvents, err := eventRepo.ListByDay(ctx, 1, today, noTags)
 	require.NoError(t, err)
 	assert.Len(t, dayEvents, 2)
 
-	weekEvents, err := eventRepo.ListByWeek(today)
+	weekEvents, err := eventRepo.ListByWeek(ctx, 1, today, noTags)
 	require.NoError(t, err)
 	assert.Len(t, weekEvents, 3)
 
-	monthEvents, err := eventRepo.ListByMonth(today)
+	monthEvents, err := eventRepo.ListByMonth(ctx, 1, today, noTags)
 	require.NoError(t, err)
 	assert.Len(t, monthEvents, 3)
+
+	home, err := domain.NewTagFilter([]string{"home"}, "")
+	require.NoError(t, err)
+	homeEvents, err := eventRepo.ListByWeek(ctx, 1, today, home)
+	require.NoError(t, err)
+	require.Len(t, homeEvents, 1)
+	assert.Equal(t, "Today Evening", homeEvents[0].Title)
 }
 
 func TestStorage_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
 	storage := NewStorage()
 	eventRepo := storage.Event()
 
@@ -139,7 +167,7 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 				Duration:  1 * time.Hour,
 				UserID:    id,
 			}
-			_ = eventRepo.Create(event)
+			_ = eventRepo.Create(ctx, event)
 			done <- true
 		}(i)
 	}
@@ -148,7 +176,255 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 
-	monthEvents, err := eventRepo.ListByMonth(time.Now())
+	count, err := eventRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestStorage_RemindersFollowEventTime(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	eventRepo := storage.Event()
+	reminderRepo := storage.Reminder()
+
+	eventTime := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	event := &domain.Event{
+		Title:     "Planning",
+		EventTime: eventTime,
+		Duration:  time.Hour,
+		UserID:    1,
+		Reminders: []domain.Reminder{
+			{Offset: 24 * time.Hour},
+			{Offset: 15 * time.Minute},
+			{Offset: 15 * time.Minute},
+		},
+	}
+	require.NoError(t, eventRepo.Create(ctx, event))
+	require.Len(t, event.Reminders, 2)
+
+	due, err := reminderRepo.ListDue(ctx, eventTime.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, eventTime.Add(-24*time.Hour), due[0].FireAt)
+
+	require.NoError(t, reminderRepo.Fire(ctx, due[0].ID, eventTime.Add(-time.Hour), &domain.OutboxMessage{}))
+
+	due, err = reminderRepo.ListDue(ctx, eventTime.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Перенос события на день вперёд пересчитывает время и сбрасывает отправленное напоминание.
+	moved := &domain.Event{
+		Title:     "Planning",
+		EventTime: eventTime.Add(24 * time.Hour),
+		Duration:  time.Hour,
+		UserID:    1,
+		Reminders: []domain.Reminder{{Offset: 24 * time.Hour}, {Offset: 15 * time.Minute}},
+	}
+	require.NoError(t, eventRepo.Update(ctx, event.ID, moved))
+
+	stored, err := eventRepo.Get(ctx, event.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Reminders, 2)
+	for _, reminder := range stored.Reminders {
+		assert.Equal(t, domain.ReminderPending, reminder.Status)
+		assert.Equal(t, moved.EventTime.Add(-reminder.Offset), reminder.FireAt)
+	}
+	assert.Equal(t, event.Reminders[0].ID, stored.Reminders[0].ID)
+
+	due, err = reminderRepo.ListDue(ctx, eventTime)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 24*time.Hour, due[0].Offset)
+}
+
+func TestStorage_SentReminderKeptWhenTimeUnchanged(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	eventRepo := storage.Event()
+	reminderRepo := storage.Reminder()
+
+	eventTime := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	event := &domain.Event{
+		Title:     "Standup",
+		EventTime: eventTime,
+		Duration:  15 * time.Minute,
+		UserID:    1,
+		Reminders: []domain.Reminder{{Offset: time.Hour}},
+	}
+	require.NoError(t, eventRepo.Create(ctx, event))
+	require.NoError(t, reminderRepo.Fire(ctx, event.Reminders[0].ID, eventTime.Add(-time.Hour), &domain.OutboxMessage{}))
+
+	renamed := *event
+	renamed.Title = "Daily standup"
+	renamed.Reminders = []domain.Reminder{{Offset: time.Hour}, {Offset: 5 * time.Minute}}
+	require.NoError(t, eventRepo.Update(ctx, event.ID, &renamed))
+
+	stored, err := eventRepo.Get(ctx, event.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Reminders, 2)
+	assert.Equal(t, domain.ReminderSent, stored.Reminders[0].Status)
+	assert.Equal(t, domain.ReminderPending, stored.Reminders[1].Status)
+
+	// Fire ставит сообщение в outbox только для ещё не отправленного напоминания.
+	msg := domain.OutboxMessage{Topic: domain.TopicNotifications, Payload: []byte("{}")}
+	require.NoError(t, reminderRepo.Fire(ctx, stored.Reminders[1].ID, time.Now(), &msg))
+	assert.Equal(t, 2, msg.ID)
+	assert.ErrorIs(t, reminderRepo.Fire(ctx, stored.Reminders[1].ID, time.Now(), &msg), domain.ErrReminderAlreadySent)
+	assert.ErrorIs(t, reminderRepo.Fire(ctx, 999, time.Now(), &msg), domain.ErrReminderNotFound)
+
+	pending, err := storage.Outbox().ClaimPending(ctx, "test", time.Now(), time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, []byte("{}"), pending[1].Payload)
+}
+
+func TestStorage_OutboxClaims(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	outbox := storage.Outbox()
+
+	now := time.Date(2025, 12, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		storage.mu.Lock()
+		storage.addOutboxMessage(&domain.OutboxMessage{Topic: domain.TopicNotifications, CreatedAt: now})
+		storage.mu.Unlock()
+	}
+
+	ids := func(messages []domain.OutboxMessage) []int {
+		result := make([]int, len(messages))
+		for i, msg := range messages {
+			result[i] = msg.ID
+		}
+		return result
+	}
+
+	claimed, err := outbox.ClaimPending(ctx, "a", now, now.Add(time.Minute), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids(claimed))
+
+	// Захваченные сообщения другой владелец не получает, пока захват не истёк.
+	claimed, err = outbox.ClaimPending(ctx, "b", now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids(claimed))
+
+	claimed, err = outbox.ClaimPending(ctx, "a", now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids(claimed))
+
+	require.NoError(t, outbox.MarkDispatched(ctx, []int{1}, now))
+	claimed, err = outbox.ClaimPending(ctx, "b", now.Add(time.Minute), now.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, ids(claimed))
+
+	processed, err := outbox.Processed(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	require.NoError(t, outbox.MarkProcessed(ctx, 1, now))
+	processed, err = outbox.Processed(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	processed, err = outbox.Processed(ctx, 999)
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestStorage_Batch(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorage()
+	eventRepo := storage.Event()
+
+	eventTime := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	events := []*domain.Event{
+		{Title: "First", EventTime: eventTime, Duration: time.Hour, UserID: 1},
+		{
+			Title: "Second", EventTime: eventTime, Duration: time.Hour, UserID: 1,
+			Reminders: []domain.Reminder{{Offset: time.Hour}},
+		},
+		{Title: "Foreign", EventTime: eventTime, Duration: time.Hour, UserID: 2},
+	}
+	require.NoError(t, eventRepo.CreateBatch(ctx, events))
+	assert.Equal(t, []int{1, 2, 3}, []int{events[0].ID, events[1].ID, events[2].ID})
+	assert.NotZero(t, events[1].Reminders[0].ID)
+
+	// Чужое событие в пакете «всё или ничего» отменяет и остальные изменения.
+	first := *events[0]
+	first.Title = "Renamed"
+	foreign := *events[2]
+	errs, err := eventRepo.UpdateBatch(ctx, 1, []*domain.Event{&first, &foreign}, domain.BatchAllOrNothing)
+	require.NoError(t, err)
+	assert.ErrorIs(t, errs[0], domain.ErrBatchAborted)
+	assert.ErrorIs(t, errs[1], domain.ErrEventNotFound)
+
+	stored, err := eventRepo.Get(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First", stored.Title)
+
+	errs, err = eventRepo.UpdateBatch(ctx, 1, []*domain.Event{&first, &foreign}, domain.BatchBestEffort)
+	require.NoError(t, err)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], domain.ErrEventNotFound)
+
+	stored, err = eventRepo.Get(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Title)
+
+	deleted, errs, err := eventRepo.DeleteBatch(ctx, 1, []int{1, 2, 2, 99}, domain.BatchBestEffort)
 	require.NoError(t, err)
-	assert.Len(t, monthEvents, 10)
+	assert.Equal(t, []error{nil, nil, domain.ErrEventNotFound, domain.ErrEventNotFound}, errs)
+	assert.Equal(t, "Renamed", deleted[0].Title)
+	assert.Len(t, deleted[1].Reminders, 1)
+
+	count, err := eventRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestStorage_AllDayListing(t *testing.T) {
+	ctx := context.Background()
+	eventRepo := NewStorage().Event()
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	vacation := &domain.Event{Title: "Vacation", UserID: 1}
+	require.NoError(t, vacation.SetDates(
+		time.Date(2025, 11, 29, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, eventRepo.Create(ctx, vacation))
+
+	// Обычное событие в полночь по Москве — ещё 30 ноября по UTC.
+	require.NoError(t, eventRepo.Create(ctx, &domain.Event{
+		Title: "Call", UserID: 1, EventTime: time.Date(2025, 12, 1, 0, 30, 0, 0, moscow), Duration: time.Hour,
+	}))
+
+	titles := func(events []domain.Event, err error) []string {
+		require.NoError(t, err)
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Title)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	for _, loc := range []*time.Location{time.UTC, moscow} {
+		assert.Equal(t, []string{"Vacation"},
+			titles(eventRepo.ListByDay(ctx, 1, time.Date(2025, 11, 29, 12, 0, 0, 0, loc), noTags)), loc)
+		assert.Equal(t, []string{"Vacation"},
+			titles(eventRepo.ListByDay(ctx, 1, time.Date(2025, 12, 2, 12, 0, 0, 0, loc), noTags)), loc)
+		assert.Empty(t, titles(eventRepo.ListByDay(ctx, 1, time.Date(2025, 12, 3, 0, 0, 0, 0, loc), noTags)), loc)
+		assert.Contains(t,
+			titles(eventRepo.ListByMonth(ctx, 1, time.Date(2025, 11, 1, 0, 0, 0, 0, loc), noTags)), "Vacation", loc)
+		assert.Contains(t,
+			titles(eventRepo.ListByMonth(ctx, 1, time.Date(2025, 12, 1, 0, 0, 0, 0, loc), noTags)), "Vacation", loc)
+	}
+
+	assert.Equal(t, []string{"Call", "Vacation"},
+		titles(eventRepo.ListByDay(ctx, 1, time.Date(2025, 12, 1, 0, 0, 0, 0, moscow), noTags)))
+	assert.Equal(t, []string{"Vacation"},
+		titles(eventRepo.ListByDay(ctx, 1, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), noTags)))
+	assert.Equal(t, []string{"Call", "Vacation"},
+		titles(eventRepo.ListByWeek(ctx, 1, time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC), noTags)))
+	assert.Equal(t, []string{"Vacation"},
+		titles(eventRepo.ListByWeek(ctx, 1, time.Date(2025, 11, 24, 0, 0, 0, 0, moscow), noTags)))
 }
@@ -0,0 +1,92 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+)
+
+type WebhookRepository struct {
+	storage *Storage
+}
+
+func (r *WebhookRepository) Create(_ context.Context, w *domain.Webhook) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	w.ID = r.storage.nextHookID
+	hook := *w
+	r.storage.webhooks[w.ID] = &hook
+	r.storage.nextHookID++
+	return nil
+}
+
+func (r *WebhookRepository) Delete(_ context.Context, id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, exists := r.storage.webhooks[id]; !exists {
+		return domain.ErrWebhookNotFound
+	}
+
+	delete(r.storage.webhooks, id)
+
+	deliveries := r.storage.deliveries[:0]
+	for _, d := range r.storage.deliveries {
+		if d.WebhookID != id {
+			deliveries = append(deliveries, d)
+		}
+	}
+	r.storage.deliveries = deliveries
+	return nil
+}
+
+func (r *WebhookRepository) Get(_ context.Context, id int) (domain.Webhook, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	hook, exists := r.storage.webhooks[id]
+	if !exists {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return *hook, nil
+}
+
+func (r *WebhookRepository) ListByUser(_ context.Context, userID int) ([]domain.Webhook, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var hooks []domain.Webhook
+	for _, hook := range r.storage.webhooks {
+		if hook.UserID == userID {
+			hooks = append(hooks, *hook)
+		}
+	}
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks, nil
+}
+
+func (r *WebhookRepository) AddDelivery(_ context.Context, d *domain.WebhookDelivery) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	d.ID = r.storage.nextDelID
+	r.storage.deliveries = append(r.storage.deliveries, *d)
+	r.storage.nextDelID++
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(_ context.Context, webhookID int) ([]domain.WebhookDelivery, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.storage.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,82 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+type ChannelRepository struct {
+	db    *sqlx.DB
+	reads *readRouter
+}
+
+type channelDB struct {
+	UserID  int    `db:"user_id"`
+	Type    string `db:"type"`
+	Address string `db:"address"`
+}
+
+func (c channelDB) toDomain() domain.NotificationChannel {
+	return domain.NotificationChannel{
+		UserID:  c.UserID,
+		Type:    domain.ChannelType(c.Type),
+		Address: c.Address,
+	}
+}
+
+func (r *ChannelRepository) Get(ctx context.Context, userID int) (_ domain.NotificationChannel, err error) {
+	query := `SELECT * FROM notification_channels WHERE user_id = $1`
+
+	ctx, span := startSpan(ctx, "channels.get", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var channel channelDB
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &channel, query, userID)
+	})
+	if err != nil {
+		return domain.NotificationChannel{}, domain.ErrChannelNotFound
+	}
+
+	return channel.toDomain(), nil
+}
+
+func (r *ChannelRepository) Set(ctx context.Context, c *domain.NotificationChannel) (err error) {
+	query := `
+        INSERT INTO notification_channels (user_id, type, address)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE SET type = EXCLUDED.type, address = EXCLUDED.address
+    `
+
+	ctx, span := startSpan(ctx, "channels.set", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, query, c.UserID, string(c.Type), c.Address)
+	return err
+}
+
+func (r *ChannelRepository) Delete(ctx context.Context, userID int) (err error) {
+	query := `DELETE FROM notification_channels WHERE user_id = $1`
+
+	ctx, span := startSpan(ctx, "channels.delete", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrChannelNotFound
+	}
+
+	return nil
+}
@@ -1,14 +1,37 @@
 package sqlstorage
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var (
+	eventColumns = []string{
+		"id", "title", "event_time", "duration", "description", "user_id", "time_to_notify", "category", "color",
+		"all_day",
+	}
+	reminderColumns = []string{"id", "event_id", "remind_before", "fire_at", "status"}
+)
+
+// allDayOverlaps отбирает события на весь день, чьи дни пересекаются с днями [$3, $4).
+// Дни хранятся полуночью UTC, длительность — в наносекундах.
+const allDayOverlaps = `all_day AND event_time < $4
+            AND event_time + duration / 1000 * interval '1 microsecond' > $3`
+
+// errBatchRejected откатывает транзакцию пакета в режиме «всё или ничего».
+var errBatchRejected = errors.New("batch rejected")
+
 type EventRepository struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	reads *readRouter
 }
 
 type eventDB struct {
@@ -19,6 +42,11 @@ type eventDB struct {
 	Description  string        `db:"description"`
 	UserID       int           `db:"user_id"`
 	TimeToNotify time.Time     `db:"time_to_notify"`
+	Category     string        `db:"category"`
+	Color        string        `db:"color"`
+	AllDay       bool          `db:"all_day"`
+	// RestoredFrom — ID события в резервной копии, если Restore сохранил его под новым.
+	RestoredFrom sql.NullInt64 `db:"restored_from"`
 }
 
 func (e eventDB) toDomain() domain.Event {
@@ -30,6 +58,9 @@ func (e eventDB) toDomain() domain.Event {
 		Description:  e.Description,
 		UserID:       e.UserID,
 		TimeToNotify: e.TimeToNotify,
+		Category:     e.Category,
+		Color:        e.Color,
+		AllDay:       e.AllDay,
 	}
 }
 
@@ -42,64 +73,153 @@ func toEventDB(e domain.Event) eventDB {
 		Description:  e.Description,
 		UserID:       e.UserID,
 		TimeToNotify: e.TimeToNotify,
+		Category:     e.Category,
+		Color:        e.Color,
+		AllDay:       e.AllDay,
 	}
 }
 
-func (r *EventRepository) Create(e *domain.Event) error {
+func (r *EventRepository) Create(ctx context.Context, e *domain.Event) (err error) {
 	query := `
-        INSERT INTO events (title, event_time, duration, description, user_id, time_to_notify)
-        VALUES (:title, :event_time, :duration, :description, :user_id, :time_to_notify)
+        INSERT INTO events (title, event_time, duration, description, user_id, time_to_notify, category, color, all_day)
+        VALUES (:title, :event_time, :duration, :description, :user_id, :time_to_notify, :category, :color, :all_day)
         RETURNING id
     `
 
+	ctx, span := startSpan(ctx, "events.create", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := insertEvent(ctx, tx, query, e); err != nil {
+			return err
+		}
+
+		e.ScheduleReminders(nil)
+		if err := saveReminders(ctx, tx, e); err != nil {
+			return err
+		}
+		return saveTags(ctx, tx, e)
+	})
+}
+
+// Restore вставляет событие под его ID и сдвигает последовательность за этот ID,
+// чтобы Create не выдал его повторно. Если ID занят, событие получает новый,
+// а прежний запоминается в restored_from.
+func (r *EventRepository) Restore(ctx context.Context, e *domain.Event) (err error) {
+	insertWithID := `
+        INSERT INTO events (id, title, event_time, duration, description, user_id, time_to_notify, category, color,
+            all_day)
+        VALUES (:id, :title, :event_time, :duration, :description, :user_id, :time_to_notify, :category, :color,
+            :all_day)
+        ON CONFLICT (id) DO NOTHING
+        RETURNING id
+    `
+	insert := `
+        INSERT INTO events (title, event_time, duration, description, user_id, time_to_notify, category, color,
+            all_day, restored_from)
+        VALUES (:title, :event_time, :duration, :description, :user_id, :time_to_notify, :category, :color,
+            :all_day, NULLIF(:id, 0))
+        RETURNING id
+    `
+	syncSequence := `
+        SELECT setval(pg_get_serial_sequence('events', 'id'), $1)
+        WHERE $1 > COALESCE(pg_sequence_last_value(pg_get_serial_sequence('events', 'id')::regclass), 0)
+    `
+
+	ctx, span := startSpan(ctx, "events.restore", insertWithID)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		restored := false
+		if e.ID > 0 {
+			var err error
+			if restored, err = insertEvent(ctx, tx, insertWithID, e); err != nil {
+				return err
+			}
+		}
+
+		if restored {
+			if _, err := tx.ExecContext(ctx, syncSequence, e.ID); err != nil {
+				return err
+			}
+		} else if _, err := insertEvent(ctx, tx, insert, e); err != nil {
+			return err
+		}
+
+		e.RestoreReminders()
+		if err := saveReminders(ctx, tx, e); err != nil {
+			return err
+		}
+		return saveTags(ctx, tx, e)
+	})
+}
+
+// insertEvent выполняет INSERT ... RETURNING id и проставляет e.ID; false — строка не вставлена.
+func insertEvent(ctx context.Context, tx *sqlx.Tx, query string, e *domain.Event) (bool, error) {
 	eventDB := toEventDB(*e)
-	rows, err := r.db.NamedQuery(query, &eventDB)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, &eventDB)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer rows.Close()
 
-	if rows.Next() {
-		if err := rows.Scan(&e.ID); err != nil {
-			return err
-		}
+	if !rows.Next() {
+		return false, rows.Err()
 	}
-
-	return nil
+	return true, rows.Scan(&e.ID)
 }
 
-func (r *EventRepository) Update(id int, e *domain.Event) error {
+func (r *EventRepository) Update(ctx context.Context, id int, e *domain.Event) (err error) {
 	query := `
         UPDATE events 
         SET title = :title, event_time = :event_time, duration = :duration,
-            description = :description, user_id = :user_id, time_to_notify = :time_to_notify
+            description = :description, user_id = :user_id, time_to_notify = :time_to_notify,
+            category = :category, color = :color, all_day = :all_day
         WHERE id = :id
     `
 
-	eventDB := toEventDB(*e)
-	eventDB.ID = id
+	ctx, span := startSpan(ctx, "events.update", query)
+	defer func() { tracing.EndSpan(span, err) }()
 
-	result, err := r.db.NamedExec(query, &eventDB)
-	if err != nil {
-		return err
-	}
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		eventDB := toEventDB(*e)
+		eventDB.ID = id
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+		result, err := tx.NamedExecContext(ctx, query, &eventDB)
+		if err != nil {
+			return err
+		}
 
-	if rowsAffected == 0 {
-		return domain.ErrEventNotFound
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	return nil
+		if rowsAffected == 0 {
+			return domain.ErrEventNotFound
+		}
+
+		previous, err := selectReminders(ctx, tx, []int{id})
+		if err != nil {
+			return err
+		}
+
+		e.ID = id
+		e.ScheduleReminders(previous[id])
+		if err := saveReminders(ctx, tx, e); err != nil {
+			return err
+		}
+		return saveTags(ctx, tx, e)
+	})
 }
 
-func (r *EventRepository) Delete(id int) error {
+func (r *EventRepository) Delete(ctx context.Context, id int) (err error) {
 	query := `DELETE FROM events WHERE id = $1`
 
-	result, err := r.db.Exec(query, id)
+	ctx, span := startSpan(ctx, "events.delete", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -116,75 +236,328 @@ func (r *EventRepository) Delete(id int) error {
 	return nil
 }
 
-func (r *EventRepository) Get(id int) (domain.Event, error) {
+func (r *EventRepository) Get(ctx context.Context, id int) (_ domain.Event, err error) {
 	query := `SELECT * FROM events WHERE id = $1`
 
-	var event eventDB
-	err := r.db.Get(&event, query, id)
-	if err != nil {
+	ctx, span := startSpan(ctx, "events.get", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var events []domain.Event
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		var event eventDB
+		if err := db.GetContext(ctx, &event, query, id); err != nil {
+			return err
+		}
+
+		var err error
+		events, err = withDetails(ctx, db, []eventDB{event})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
 		return domain.Event{}, domain.ErrEventNotFound
 	}
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	return events[0], nil
+}
+
+func (r *EventRepository) ListByDay(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.listPeriod(ctx, "events.list_by_day", userID, startOfDay, startOfDay.AddDate(0, 0, 1), tags)
+}
+
+func (r *EventRepository) ListByWeek(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	startOfWeek := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.listPeriod(ctx, "events.list_by_week", userID, startOfWeek, startOfWeek.Add(7*24*time.Hour), tags)
+}
 
-	return event.toDomain(), nil
+func (r *EventRepository) ListByMonth(
+	ctx context.Context, userID int, date time.Time, tags domain.TagFilter,
+) ([]domain.Event, error) {
+	startOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return r.listPeriod(ctx, "events.list_by_month", userID, startOfMonth, startOfMonth.AddDate(0, 1, 0), tags)
 }
 
-func (r *EventRepository) ListByDay(date time.Time) ([]domain.Event, error) {
+func (r *EventRepository) listPeriod(
+	ctx context.Context, spanName string, userID int, start, end time.Time, tags domain.TagFilter,
+) (_ []domain.Event, err error) {
 	query := `
-        SELECT * FROM events 
-        WHERE event_time::date = $1::date
+        SELECT * FROM events e
+        WHERE e.user_id = $5
+            AND ((NOT all_day AND event_time >= $1 AND event_time < $2) OR (` + allDayOverlaps + `))
+            AND ` + tagsMatch("$6", "$7") + `
         ORDER BY event_time
     `
 
-	var eventsDB []eventDB
-	err := r.db.Select(&eventsDB, query, date)
-	if err != nil {
-		return nil, err
-	}
+	ctx, span := startSpan(ctx, spanName, query)
+	defer func() { tracing.EndSpan(span, err) }()
 
-	events := make([]domain.Event, len(eventsDB))
-	for i, event := range eventsDB {
-		events[i] = event.toDomain()
-	}
+	return r.selectEvents(ctx, query, start, end, domain.Date(start), domain.Date(end),
+		userID, pq.Array(tags.Tags), tags.Match == domain.TagMatchAny)
+}
 
-	return events, nil
+func (r *EventRepository) ListAfter(ctx context.Context, userID, afterID, limit int) (_ []domain.Event, err error) {
+	query := `SELECT * FROM events WHERE id > $1 AND ($2 = 0 OR user_id = $2) ORDER BY id LIMIT $3`
+
+	ctx, span := startSpan(ctx, "events.list_after", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return r.selectEvents(ctx, query, afterID, userID, limit)
 }
 
-func (r *EventRepository) ListByWeek(date time.Time) ([]domain.Event, error) {
-	startOfWeek := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfWeek := startOfWeek.Add(7 * 24 * time.Hour)
+func (r *EventRepository) ListRestored(ctx context.Context, sourceID int) (_ []domain.Event, err error) {
+	query := `SELECT * FROM events WHERE restored_from = $1 ORDER BY id`
+
+	ctx, span := startSpan(ctx, "events.list_restored", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return r.selectEvents(ctx, query, sourceID)
+}
+
+func (r *EventRepository) Count(ctx context.Context) (_ int, err error) {
+	query := `SELECT COUNT(*) FROM events`
+
+	ctx, span := startSpan(ctx, "events.count", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var count int
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &count, query)
+	})
+	return count, err
+}
 
+// CreateBatch заранее резервирует ID из последовательностей и загружает события
+// и напоминания через COPY, поэтому число запросов не зависит от размера пакета.
+func (r *EventRepository) CreateBatch(ctx context.Context, events []*domain.Event) (err error) {
+	ctx, span := startSpan(ctx, "events.create_batch", pq.CopyIn("events", eventColumns...))
+	span.SetAttributes(attribute.Int("db.batch_size", len(events)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		ids, err := reserveIDs(ctx, tx, "events", len(events))
+		if err != nil {
+			return err
+		}
+
+		eventRows := make([][]any, len(events))
+		reminderCount := 0
+		for i, e := range events {
+			e.ID = ids[i]
+			e.ScheduleReminders(nil)
+			reminderCount += len(e.Reminders)
+
+			eventRows[i] = []any{
+				e.ID, e.Title, e.EventTime, int64(e.Duration), e.Description, e.UserID, e.TimeToNotify, e.Category, e.Color,
+				e.AllDay,
+			}
+		}
+
+		if err := copyRows(ctx, tx, "events", eventColumns, eventRows); err != nil {
+			return err
+		}
+		if err := copyTags(ctx, tx, events); err != nil {
+			return err
+		}
+		if reminderCount == 0 {
+			return nil
+		}
+
+		reminderIDs, err := reserveIDs(ctx, tx, "event_reminders", reminderCount)
+		if err != nil {
+			return err
+		}
+
+		reminderRows := make([][]any, 0, reminderCount)
+		for _, e := range events {
+			for i := range e.Reminders {
+				reminder := &e.Reminders[i]
+				reminder.ID = reminderIDs[len(reminderRows)]
+				reminderRows = append(reminderRows, []any{
+					reminder.ID, e.ID, int64(reminder.Offset), reminder.FireAt, string(reminder.Status),
+				})
+			}
+		}
+
+		return copyRows(ctx, tx, "event_reminders", reminderColumns, reminderRows)
+	})
+}
+
+func (r *EventRepository) UpdateBatch(
+	ctx context.Context, userID int, events []*domain.Event, mode domain.BatchMode,
+) (_ []error, err error) {
 	query := `
-        SELECT * FROM events 
-        WHERE event_time >= $1 AND event_time < $2
-        ORDER BY event_time
+        UPDATE events
+        SET title = $1, event_time = $2, duration = $3, description = $4, time_to_notify = $5,
+            category = $6, color = $7, all_day = $8
+        WHERE id = $9 AND user_id = $10
     `
 
-	var eventsDB []eventDB
-	err := r.db.Select(&eventsDB, query, startOfWeek, endOfWeek)
+	ctx, span := startSpan(ctx, "events.update_batch", query)
+	span.SetAttributes(attribute.Int("db.batch_size", len(events)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	errs := make([]error, len(events))
+	err = withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		ids := make([]int, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+
+		previous, err := selectReminders(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, e := range events {
+			result, err := stmt.ExecContext(ctx,
+				e.Title, e.EventTime, int64(e.Duration), e.Description, e.TimeToNotify, e.Category, e.Color, e.AllDay,
+				e.ID, userID)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rowsAffected == 0 {
+				errs[i] = domain.ErrEventNotFound
+				continue
+			}
+
+			e.UserID = userID
+			e.ScheduleReminders(previous[e.ID])
+			if err := saveReminders(ctx, tx, e); err != nil {
+				return err
+			}
+			if err := saveTags(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+
+		if mode == domain.BatchAllOrNothing && domain.BatchFailed(errs) {
+			return errBatchRejected
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBatchRejected) {
+		domain.AbortBatch(errs)
+		return errs, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	return errs, nil
+}
 
-	events := make([]domain.Event, len(eventsDB))
-	for i, event := range eventsDB {
-		events[i] = event.toDomain()
+func (r *EventRepository) DeleteBatch(
+	ctx context.Context, userID int, ids []int, mode domain.BatchMode,
+) (_ []domain.Event, _ []error, err error) {
+	query := `DELETE FROM events WHERE id = ANY($1) AND user_id = $2 RETURNING *`
+
+	ctx, span := startSpan(ctx, "events.delete_batch", query)
+	span.SetAttributes(attribute.Int("db.batch_size", len(ids)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	deleted := make([]domain.Event, len(ids))
+	errs := make([]error, len(ids))
+	err = withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Напоминания и теги удаляются каскадно, поэтому читаются до удаления событий.
+		reminders, err := selectReminders(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		tags, err := selectTags(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		var eventsDB []eventDB
+		if err := tx.SelectContext(ctx, &eventsDB, query, pq.Array(ids), userID); err != nil {
+			return err
+		}
+
+		byID := make(map[int]eventDB, len(eventsDB))
+		for _, event := range eventsDB {
+			byID[event.ID] = event
+		}
+
+		for i, id := range ids {
+			event, ok := byID[id]
+			if !ok {
+				errs[i] = domain.ErrEventNotFound
+				continue
+			}
+			// Повтор ID в пакете удаляет событие только один раз.
+			delete(byID, id)
+			deleted[i] = event.toDomain()
+			deleted[i].Reminders = reminders[id]
+			deleted[i].Tags = tags[id]
+		}
+
+		if mode == domain.BatchAllOrNothing && domain.BatchFailed(errs) {
+			return errBatchRejected
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBatchRejected) {
+		domain.AbortBatch(errs)
+		return make([]domain.Event, len(ids)), errs, nil
+	}
+	if err != nil {
+		return nil, nil, err
 	}
+	return deleted, errs, nil
+}
 
-	return events, nil
+// selectEvents читает события вместе с напоминаниями и тегами из одной базы.
+func (r *EventRepository) selectEvents(ctx context.Context, query string, args ...any) ([]domain.Event, error) {
+	var events []domain.Event
+	err := r.reads.read(ctx, func(db *sqlx.DB) error {
+		var eventsDB []eventDB
+		if err := db.SelectContext(ctx, &eventsDB, query, args...); err != nil {
+			return err
+		}
+
+		var err error
+		events, err = withDetails(ctx, db, eventsDB)
+		return err
+	})
+	return events, err
 }
 
-func (r *EventRepository) ListByMonth(date time.Time) ([]domain.Event, error) {
-	startOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
-	startOfNextMonth := startOfMonth.AddDate(0, 1, 0)
+// withDetails дополняет события напоминаниями и тегами.
+func withDetails(ctx context.Context, db sqlx.QueryerContext, eventsDB []eventDB) ([]domain.Event, error) {
+	ids := make([]int, len(eventsDB))
+	for i, event := range eventsDB {
+		ids[i] = event.ID
+	}
 
-	query := `
-        SELECT * FROM events 
-        WHERE event_time >= $1 AND event_time < $2
-        ORDER BY event_time
-    `
+	reminders, err := selectReminders(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
 
-	var eventsDB []eventDB
-	err := r.db.Select(&eventsDB, query, startOfMonth, startOfNextMonth)
+	tags, err := selectTags(ctx, db, ids)
 	if err != nil {
 		return nil, err
 	}
@@ -192,6 +565,8 @@ func (r *EventRepository) ListByMonth(date time.Time) ([]domain.Event, error) {
 	events := make([]domain.Event, len(eventsDB))
 	for i, event := range eventsDB {
 		events[i] = event.toDomain()
+		events[i].Reminders = reminders[event.ID]
+		events[i].Tags = tags[event.ID]
 	}
 
 	return events, nil
@@ -0,0 +1,141 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+type idempotencyDB struct {
+	UserID      int       `db:"user_id"`
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	Owner       string    `db:"owner"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func (r idempotencyDB) toDomain() domain.IdempotencyRecord {
+	return domain.IdempotencyRecord{
+		UserID:      r.UserID,
+		Key:         r.Key,
+		RequestHash: r.RequestHash,
+		Owner:       r.Owner,
+		Response:    r.Response,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+}
+
+// Begin занимает ключ одним INSERT: просроченная запись перезаписывается, действующая
+// остаётся, и тогда она читается отдельным запросом. Если между запросами запись успела
+// истечь и удалиться, попытка повторяется.
+func (r *IdempotencyRepository) Begin(
+	ctx context.Context, rec domain.IdempotencyRecord,
+) (_ domain.IdempotencyRecord, _ bool, err error) {
+	insert := `
+        INSERT INTO idempotency_keys (user_id, key, request_hash, owner, response, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, NULL, $5, $6)
+        ON CONFLICT (user_id, key) DO UPDATE SET
+            request_hash = EXCLUDED.request_hash,
+            owner = EXCLUDED.owner,
+            response = NULL,
+            created_at = EXCLUDED.created_at,
+            expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+    `
+	query := `SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at > $3`
+
+	ctx, span := startSpan(ctx, "idempotency.begin", insert)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for {
+		result, err := r.db.ExecContext(ctx, insert,
+			rec.UserID, rec.Key, rec.RequestHash, rec.Owner, rec.CreatedAt, rec.ExpiresAt)
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return domain.IdempotencyRecord{}, false, err
+		}
+		if inserted > 0 {
+			rec.Response = nil
+			return rec, true, nil
+		}
+
+		var existing idempotencyDB
+		err = r.db.GetContext(ctx, &existing, query, rec.UserID, rec.Key, rec.CreatedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			continue
+		case err != nil:
+			return domain.IdempotencyRecord{}, false, err
+		}
+		return existing.toDomain(), false, nil
+	}
+}
+
+func (r *IdempotencyRepository) Complete(
+	ctx context.Context, userID int, key, owner string, response []byte, expiresAt time.Time,
+) (err error) {
+	query := `
+        UPDATE idempotency_keys SET response = $4, expires_at = $5
+        WHERE user_id = $1 AND key = $2 AND owner = $3
+    `
+
+	ctx, span := startSpan(ctx, "idempotency.complete", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Пустой ответ хранится как пустой массив, а не NULL, иначе запись считалась бы незавершённой.
+	result, err := r.db.ExecContext(ctx, query, userID, key, owner, append([]byte{}, response...), expiresAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID int, key, owner string) (err error) {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND owner = $3`
+
+	ctx, span := startSpan(ctx, "idempotency.delete", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, query, userID, key, owner)
+	return err
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (_ int, err error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	ctx, span := startSpan(ctx, "idempotency.delete_expired", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
@@ -0,0 +1,138 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+type outboxDB struct {
+	ID           int            `db:"id"`
+	Topic        string         `db:"topic"`
+	Payload      []byte         `db:"payload"`
+	CreatedAt    time.Time      `db:"created_at"`
+	DispatchedAt sql.NullTime   `db:"dispatched_at"`
+	ClaimedBy    sql.NullString `db:"claimed_by"`
+	ClaimedUntil sql.NullTime   `db:"claimed_until"`
+	ProcessedAt  sql.NullTime   `db:"processed_at"`
+}
+
+func (m outboxDB) toDomain() domain.OutboxMessage {
+	return domain.OutboxMessage{
+		ID:           m.ID,
+		Topic:        m.Topic,
+		Payload:      m.Payload,
+		CreatedAt:    m.CreatedAt,
+		DispatchedAt: m.DispatchedAt.Time,
+		ClaimedBy:    m.ClaimedBy.String,
+		ClaimedUntil: m.ClaimedUntil.Time,
+		ProcessedAt:  m.ProcessedAt.Time,
+	}
+}
+
+func (r *OutboxRepository) ClaimPending(
+	ctx context.Context, owner string, now, until time.Time, limit int,
+) (_ []domain.OutboxMessage, err error) {
+	query := `
+        UPDATE outbox SET claimed_by = $1, claimed_until = $3
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE dispatched_at IS NULL
+              AND (claimed_until IS NULL OR claimed_until <= $2 OR claimed_by = $1)
+            ORDER BY id
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING *
+    `
+
+	ctx, span := startSpan(ctx, "outbox.claim_pending", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var messagesDB []outboxDB
+	if err := r.db.SelectContext(ctx, &messagesDB, query, owner, now, until, limit); err != nil {
+		return nil, err
+	}
+
+	// RETURNING не гарантирует порядок строк.
+	messages := toDomainMessages(messagesDB)
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, ids []int, dispatchedAt time.Time) (err error) {
+	query := `UPDATE outbox SET dispatched_at = $1 WHERE id = ANY($2) AND dispatched_at IS NULL`
+
+	ctx, span := startSpan(ctx, "outbox.mark_dispatched", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, query, dispatchedAt, pq.Array(ids))
+	return err
+}
+
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, id int, processedAt time.Time) (err error) {
+	query := `UPDATE outbox SET processed_at = $1 WHERE id = $2 AND processed_at IS NULL`
+
+	ctx, span := startSpan(ctx, "outbox.mark_processed", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, query, processedAt, id)
+	return err
+}
+
+func (r *OutboxRepository) Processed(ctx context.Context, id int) (_ bool, err error) {
+	query := `SELECT EXISTS (SELECT 1 FROM outbox WHERE id = $1 AND processed_at IS NOT NULL)`
+
+	ctx, span := startSpan(ctx, "outbox.processed", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var processed bool
+	err = r.db.GetContext(ctx, &processed, query, id)
+	return processed, err
+}
+
+func (r *OutboxRepository) DeleteDispatched(ctx context.Context, before time.Time) (_ int, err error) {
+	query := `DELETE FROM outbox WHERE dispatched_at < $1`
+
+	ctx, span := startSpan(ctx, "outbox.delete_dispatched", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
+
+func toDomainMessages(messagesDB []outboxDB) []domain.OutboxMessage {
+	messages := make([]domain.OutboxMessage, len(messagesDB))
+	for i, msg := range messagesDB {
+		messages[i] = msg.toDomain()
+	}
+	return messages
+}
+
+func insertOutboxMessage(ctx context.Context, tx *sqlx.Tx, msg *domain.OutboxMessage) (err error) {
+	query := `
+        INSERT INTO outbox (topic, payload, created_at)
+        VALUES ($1, $2, $3)
+        RETURNING id
+    `
+
+	ctx, span := startSpan(ctx, "outbox.insert", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return tx.QueryRowxContext(ctx, query, msg.Topic, msg.Payload, msg.CreatedAt).Scan(&msg.ID)
+}
@@ -0,0 +1,150 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ReminderRepository struct {
+	db *sqlx.DB
+}
+
+type reminderDB struct {
+	ID           int           `db:"id"`
+	EventID      int           `db:"event_id"`
+	RemindBefore time.Duration `db:"remind_before"`
+	FireAt       time.Time     `db:"fire_at"`
+	Status       string        `db:"status"`
+	SentAt       sql.NullTime  `db:"sent_at"`
+}
+
+func (r reminderDB) toDomain() domain.Reminder {
+	return domain.Reminder{
+		ID:      r.ID,
+		EventID: r.EventID,
+		Offset:  r.RemindBefore,
+		FireAt:  r.FireAt,
+		Status:  domain.ReminderStatus(r.Status),
+		SentAt:  r.SentAt.Time,
+	}
+}
+
+func (r *ReminderRepository) ListDue(ctx context.Context, now time.Time) (_ []domain.Reminder, err error) {
+	query := `
+        SELECT * FROM event_reminders
+        WHERE status = $1 AND fire_at <= $2
+        ORDER BY fire_at
+    `
+
+	ctx, span := startSpan(ctx, "reminders.list_due", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var remindersDB []reminderDB
+	// Только основная база: с отставшей реплики уже отправленные напоминания ушли бы повторно.
+	if err := r.db.SelectContext(ctx, &remindersDB, query, string(domain.ReminderPending), now); err != nil {
+		return nil, err
+	}
+
+	reminders := make([]domain.Reminder, len(remindersDB))
+	for i, reminder := range remindersDB {
+		reminders[i] = reminder.toDomain()
+	}
+
+	return reminders, nil
+}
+
+// Fire блокирует строку напоминания, поэтому два планировщика не поставят его в outbox дважды.
+func (r *ReminderRepository) Fire(
+	ctx context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage,
+) (err error) {
+	selectQuery := `SELECT status FROM event_reminders WHERE id = $1 FOR UPDATE`
+	updateQuery := `UPDATE event_reminders SET status = $1, sent_at = $2 WHERE id = $3`
+
+	ctx, span := startSpan(ctx, "reminders.fire", updateQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var status string
+		err := tx.GetContext(ctx, &status, selectQuery, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrReminderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if domain.ReminderStatus(status) == domain.ReminderSent {
+			return domain.ErrReminderAlreadySent
+		}
+
+		if _, err := tx.ExecContext(ctx, updateQuery, string(domain.ReminderSent), sentAt, id); err != nil {
+			return err
+		}
+		return insertOutboxMessage(ctx, tx, msg)
+	})
+}
+
+func selectReminders(
+	ctx context.Context, q sqlx.QueryerContext, eventIDs []int,
+) (_ map[int][]domain.Reminder, err error) {
+	query := `SELECT * FROM event_reminders WHERE event_id = ANY($1) ORDER BY remind_before DESC`
+
+	ctx, span := startSpan(ctx, "reminders.select", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var remindersDB []reminderDB
+	if err := sqlx.SelectContext(ctx, q, &remindersDB, query, pq.Array(eventIDs)); err != nil {
+		return nil, err
+	}
+
+	reminders := make(map[int][]domain.Reminder, len(eventIDs))
+	for _, reminder := range remindersDB {
+		reminders[reminder.EventID] = append(reminders[reminder.EventID], reminder.toDomain())
+	}
+
+	return reminders, nil
+}
+
+// saveReminders приводит напоминания события в БД к e.Reminders и проставляет им ID.
+func saveReminders(ctx context.Context, tx *sqlx.Tx, e *domain.Event) (err error) {
+	deleteQuery := `DELETE FROM event_reminders WHERE event_id = $1 AND NOT (remind_before = ANY($2))`
+	upsertQuery := `
+        INSERT INTO event_reminders (event_id, remind_before, fire_at, status, sent_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (event_id, remind_before) DO UPDATE
+        SET fire_at = EXCLUDED.fire_at, status = EXCLUDED.status, sent_at = EXCLUDED.sent_at
+        RETURNING id
+    `
+
+	ctx, span := startSpan(ctx, "reminders.save", upsertQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	offsets := make([]int64, len(e.Reminders))
+	for i, reminder := range e.Reminders {
+		offsets[i] = int64(reminder.Offset)
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, e.ID, pq.Array(offsets)); err != nil {
+		return err
+	}
+
+	for i := range e.Reminders {
+		reminder := &e.Reminders[i]
+		sentAt := sql.NullTime{Time: reminder.SentAt, Valid: !reminder.SentAt.IsZero()}
+
+		err := tx.QueryRowContext(ctx, upsertQuery,
+			e.ID, int64(reminder.Offset), reminder.FireAt, string(reminder.Status), sentAt,
+		).Scan(&reminder.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// replicaRetryDelay — сколько после сбоя реплики чтение идёт в основную базу.
+const replicaRetryDelay = 10 * time.Second
+
+// readRouter отправляет чтение на реплику, а пока она недоступна — в основную базу.
+// Без реплики всё читается из основной базы.
+type readRouter struct {
+	primary   *sqlx.DB
+	replica   *sqlx.DB
+	downUntil atomic.Int64
+	now       func() time.Time
+}
+
+func newReadRouter(primary, replica *sqlx.DB) *readRouter {
+	return &readRouter{primary: primary, replica: replica, now: time.Now}
+}
+
+// read выполняет fn на реплике, а если с ней нет соединения — повторяет на основной базе.
+// Остальные ошибки запроса возвращаются как есть. Все запросы fn идут в одну базу,
+// поэтому события и их напоминания читаются согласованно. Контекст с storage.WithPrimary
+// читается сразу из основной базы.
+func (r *readRouter) read(ctx context.Context, fn func(db *sqlx.DB) error) error {
+	if r.replica != nil && !storage.PrimaryRequired(ctx) && r.now().UnixNano() >= r.downUntil.Load() {
+		err := fn(r.replica)
+		if !isConnectionError(err) {
+			return err
+		}
+		r.markDown()
+	}
+	return fn(r.primary)
+}
+
+func (r *readRouter) markDown() {
+	r.downUntil.Store(r.now().Add(replicaRetryDelay).UnixNano())
+}
+
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08 — ошибки соединения, 57P — сервер остановлен или ещё не принимает подключения.
+		return pqErr.Code.Class() == "08" || strings.HasPrefix(string(pqErr.Code), "57P")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,114 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRouter(t *testing.T) {
+	primary, replica := &sqlx.DB{}, &sqlx.DB{}
+	now := time.Now()
+	router := newReadRouter(primary, replica)
+	router.now = func() time.Time { return now }
+
+	var used []*sqlx.DB
+	replicaErr := driver.ErrBadConn
+	read := func() error {
+		return router.read(context.Background(), func(db *sqlx.DB) error {
+			used = append(used, db)
+			if db == replica {
+				return replicaErr
+			}
+			return nil
+		})
+	}
+
+	// Сбой соединения с репликой: запрос повторяется на основной базе.
+	require.NoError(t, read())
+	assert.Equal(t, []*sqlx.DB{replica, primary}, used)
+
+	// Пока не истекла пауза, реплика не используется.
+	used = nil
+	require.NoError(t, read())
+	assert.Equal(t, []*sqlx.DB{primary}, used)
+
+	// После паузы реплика снова получает чтение, ошибки запроса не переключают базу.
+	now = now.Add(replicaRetryDelay)
+	used = nil
+	replicaErr = errors.New("syntax error")
+	assert.Equal(t, replicaErr, read())
+	assert.Equal(t, []*sqlx.DB{replica}, used)
+
+	used = nil
+	replicaErr = nil
+	require.NoError(t, read())
+	assert.Equal(t, []*sqlx.DB{replica}, used)
+
+	// Чтение перед записью идёт в основную базу, даже когда реплика доступна.
+	used = nil
+	err := router.read(storage.WithPrimary(context.Background()), func(db *sqlx.DB) error {
+		used = append(used, db)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*sqlx.DB{primary}, used)
+}
+
+func TestReadRouter_NoReplica(t *testing.T) {
+	primary := &sqlx.DB{}
+	router := newReadRouter(primary, nil)
+
+	err := router.read(context.Background(), func(db *sqlx.DB) error {
+		assert.Same(t, primary, db)
+		return driver.ErrBadConn
+	})
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("query: %w", context.Canceled), false},
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{&pq.Error{Code: "08006"}, true},
+		{&pq.Error{Code: "57P03"}, true},
+		{&pq.Error{Code: "57014"}, false}, // запрос отменён по statement_timeout
+		{&pq.Error{Code: "23505"}, false},
+	} {
+		assert.Equal(t, tc.want, isConnectionError(tc.err), "%v", tc.err)
+	}
+}
+
+func TestWithStatementTimeout(t *testing.T) {
+	dsn, err := withStatementTimeout("host=db dbname=calendar", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "host=db dbname=calendar", dsn)
+
+	dsn, err = withStatementTimeout("host=db dbname=calendar", 1500*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "host=db dbname=calendar statement_timeout=1500", dsn)
+
+	dsn, err = withStatementTimeout("postgres://user:pass@db:5432/calendar?sslmode=disable", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "dbname='calendar' host='db' password='pass' port='5432' sslmode='disable' user='user'"+
+		" statement_timeout=1000", dsn)
+
+	_, err = withStatementTimeout("postgres://db:port/calendar", time.Second)
+	assert.Error(t, err)
+}
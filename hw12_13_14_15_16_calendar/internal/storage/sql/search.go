@@ -0,0 +1,98 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// searchVector повторяет выражение индекса events_search_idx из миграции.
+const searchVector = `(setweight(to_tsvector('simple', title), 'A') ||
+    setweight(to_tsvector('simple', description), 'B'))`
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=%d, MinWords=%d",
+	domain.HighlightStart, domain.HighlightStop, domain.SnippetMaxWords, domain.SnippetMaxWords/3)
+
+// tagsMatch — условие фильтра тегов для события e: фильтр пуст, у события есть хотя бы один
+// из тегов tags (при anyTag) или все они.
+func tagsMatch(tags, anyTag string) string {
+	return `(COALESCE(cardinality(` + tags + `::text[]), 0) = 0
+            OR (SELECT COUNT(*) FROM event_tags t WHERE t.event_id = e.id AND t.tag = ANY(` + tags + `))
+                >= CASE WHEN ` + anyTag + ` THEN 1 ELSE cardinality(` + tags + `::text[]) END)`
+}
+
+type searchResultDB struct {
+	eventDB
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+func (r *EventRepository) Search(ctx context.Context, q domain.SearchQuery) (_ []domain.SearchResult, err error) {
+	query := `
+        SELECT e.*, ts_rank(` + searchVector + `, q) AS rank,
+            ts_headline('simple',
+                CASE WHEN to_tsvector('simple', e.description) @@ q THEN e.description ELSE e.title END,
+                q, $6) AS snippet
+        FROM events e, to_tsquery('simple', $1) q
+        WHERE ` + searchVector + ` @@ q
+            AND ($2 = 0 OR e.user_id = $2)
+            AND ($3::timestamp IS NULL OR e.event_time >= $3)
+            AND ($4::timestamp IS NULL OR e.event_time < $4)
+            AND ` + tagsMatch("$7", "$8") + `
+        ORDER BY rank DESC, e.event_time
+        LIMIT $5
+    `
+
+	ctx, span := startSpan(ctx, "events.search", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var resultsDB []searchResultDB
+	var events []domain.Event
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		resultsDB = nil
+		err := db.SelectContext(ctx, &resultsDB, query,
+			prefixQuery(q.Text), q.UserID, nullTime(q.From), nullTime(q.To), q.Limit, headlineOptions,
+			pq.Array(q.Tags.Tags), q.Tags.Match == domain.TagMatchAny)
+		if err != nil {
+			return err
+		}
+
+		eventsDB := make([]eventDB, len(resultsDB))
+		for i, result := range resultsDB {
+			eventsDB[i] = result.eventDB
+		}
+
+		events, err = withDetails(ctx, db, eventsDB)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SearchResult, len(resultsDB))
+	for i, result := range resultsDB {
+		results[i] = domain.SearchResult{Event: events[i], Rank: result.Rank, Snippet: result.Snippet}
+	}
+	return results, nil
+}
+
+// prefixQuery строит tsquery, в котором каждое слово запроса обязательно и ищется по префиксу.
+// Tokenize оставляет только буквы и цифры, поэтому операторы tsquery в запрос не попадут.
+func prefixQuery(text string) string {
+	tokens := domain.Tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = token + ":*"
+	}
+	return strings.Join(tokens, " & ")
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
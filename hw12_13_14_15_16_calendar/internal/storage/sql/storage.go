@@ -1,31 +1,173 @@
 package sqlstorage
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	replica *sqlx.DB
+	reads   *readRouter
+}
+
+// NewStorage подключается к основной базе и, если задана, к реплике для чтения.
+// Недоступная при старте реплика не мешает запуску: чтение пойдёт в основную базу.
+func NewStorage(conf config.StorageConf) (*Storage, error) {
+	db, err := open(conf.Dsn, conf.Pool)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		return nil, err
+	}
+
+	s := &Storage{db: db}
+	if conf.ReplicaDsn != "" {
+		if s.replica, err = open(conf.ReplicaDsn, conf.Pool); err != nil && s.replica == nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	s.reads = newReadRouter(s.db, s.replica)
+	if err != nil {
+		s.reads.markDown()
+	}
+	return s, nil
 }
 
-func NewStorage(connectionString string) (*Storage, error) {
-	db, err := sqlx.Connect("postgres", connectionString)
+// open настраивает пул и проверяет соединение; при ошибке проверки пул всё равно возвращается.
+func open(dsn string, conf config.PoolConf) (*sqlx.DB, error) {
+	dsn, err := withStatementTimeout(dsn, conf.StatementTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
 		return nil, err
 	}
 
-	return &Storage{db: db}, nil
+	db.SetMaxOpenConns(conf.MaxOpenConns)
+	db.SetMaxIdleConns(conf.MaxIdleConns)
+	db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+
+	return db, db.Ping()
+}
+
+// withStatementTimeout добавляет к строке подключения параметр statement_timeout:
+// lib/pq передаёт серверу неизвестные ему параметры как настройки сессии.
+func withStatementTimeout(dsn string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return dsn, nil
+	}
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		var err error
+		if dsn, err = pq.ParseURL(dsn); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s statement_timeout=%d", dsn, timeout.Milliseconds()), nil
+}
+
+func withTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *Storage) Close() error {
+	if s.replica != nil {
+		s.replica.Close()
+	}
 	return s.db.Close()
 }
 
 func (s *Storage) Event() storage.EventRepository {
-	return &EventRepository{db: s.db}
+	return &EventRepository{db: s.db, reads: s.reads}
+}
+
+func (s *Storage) Webhook() storage.WebhookRepository {
+	return &WebhookRepository{db: s.db, reads: s.reads}
+}
+
+func (s *Storage) Channel() storage.ChannelRepository {
+	return &ChannelRepository{db: s.db, reads: s.reads}
+}
+
+func (s *Storage) Reminder() storage.ReminderRepository {
+	return &ReminderRepository{db: s.db}
+}
+
+func (s *Storage) Idempotency() storage.IdempotencyRepository {
+	return &IdempotencyRepository{db: s.db}
+}
+
+func (s *Storage) Outbox() storage.OutboxRepository {
+	return &OutboxRepository{db: s.db}
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) DB() *sql.DB {
+	return s.db.DB
+}
+
+// ReplicaDB возвращает пул реплики или nil, если реплика не настроена.
+func (s *Storage) ReplicaDB() *sql.DB {
+	if s.replica == nil {
+		return nil
+	}
+	return s.replica.DB
+}
+
+// reserveIDs выдаёт n значений из последовательности первичного ключа таблицы.
+func reserveIDs(ctx context.Context, tx *sqlx.Tx, table string, n int) ([]int, error) {
+	query := `SELECT nextval(pg_get_serial_sequence($1, 'id')) FROM generate_series(1, $2)`
+
+	ids := make([]int, 0, n)
+	if err := tx.SelectContext(ctx, &ids, query, table, n); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// copyRows загружает строки в таблицу через COPY FROM STDIN.
+func copyRows(ctx context.Context, tx *sqlx.Tx, table string, columns []string, rows [][]any) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+
+	// Вызов без аргументов отправляет буфер и завершает COPY.
+	_, err = stmt.ExecContext(ctx)
+	return err
 }
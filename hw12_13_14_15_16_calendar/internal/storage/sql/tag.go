@@ -0,0 +1,102 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type tagDB struct {
+	EventID int    `db:"event_id"`
+	Tag     string `db:"tag"`
+}
+
+func (r *EventRepository) TagCounts(ctx context.Context, userID int) (_ []domain.TagCount, err error) {
+	query := `
+        SELECT t.tag, COUNT(*) AS count
+        FROM event_tags t
+        JOIN events e ON e.id = t.event_id
+        WHERE e.user_id = $1
+        GROUP BY t.tag
+        ORDER BY count DESC, t.tag
+    `
+
+	ctx, span := startSpan(ctx, "events.tag_counts", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var counts []struct {
+		Tag   string `db:"tag"`
+		Count int    `db:"count"`
+	}
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		counts = nil
+		return db.SelectContext(ctx, &counts, query, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.TagCount, len(counts))
+	for i, c := range counts {
+		result[i] = domain.TagCount{Tag: c.Tag, Count: c.Count}
+	}
+	return result, nil
+}
+
+func selectTags(ctx context.Context, q sqlx.QueryerContext, eventIDs []int) (_ map[int][]string, err error) {
+	query := `SELECT event_id, tag FROM event_tags WHERE event_id = ANY($1) ORDER BY tag`
+
+	ctx, span := startSpan(ctx, "tags.select", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var tagsDB []tagDB
+	if err := sqlx.SelectContext(ctx, q, &tagsDB, query, pq.Array(eventIDs)); err != nil {
+		return nil, err
+	}
+
+	tags := make(map[int][]string, len(eventIDs))
+	for _, tag := range tagsDB {
+		tags[tag.EventID] = append(tags[tag.EventID], tag.Tag)
+	}
+	return tags, nil
+}
+
+// saveTags приводит теги события в БД к e.Tags.
+func saveTags(ctx context.Context, tx *sqlx.Tx, e *domain.Event) (err error) {
+	deleteQuery := `DELETE FROM event_tags WHERE event_id = $1 AND NOT (tag = ANY($2))`
+	insertQuery := `
+        INSERT INTO event_tags (event_id, tag)
+        SELECT $1, unnest($2::text[])
+        ON CONFLICT DO NOTHING
+    `
+
+	ctx, span := startSpan(ctx, "tags.save", insertQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Пустой, а не nil слайс, иначе ANY(NULL) не удалит ни одного тега.
+	tags := append([]string{}, e.Tags...)
+	if _, err := tx.ExecContext(ctx, deleteQuery, e.ID, pq.Array(tags)); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, insertQuery, e.ID, pq.Array(tags))
+	return err
+}
+
+func copyTags(ctx context.Context, tx *sqlx.Tx, events []*domain.Event) error {
+	var rows [][]any
+	for _, e := range events {
+		for _, tag := range e.Tags {
+			rows = append(rows, []any{e.ID, tag})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return copyRows(ctx, tx, "event_tags", []string{"event_id", "tag"}, rows)
+}
@@ -0,0 +1,21 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/sql")
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "sqlstorage."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
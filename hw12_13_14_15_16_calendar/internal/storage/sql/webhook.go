@@ -0,0 +1,174 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository struct {
+	db    *sqlx.DB
+	reads *readRouter
+}
+
+type webhookDB struct {
+	ID        int       `db:"id"`
+	UserID    int       `db:"user_id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (w webhookDB) toDomain() domain.Webhook {
+	return domain.Webhook{
+		ID:        w.ID,
+		UserID:    w.UserID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+type deliveryDB struct {
+	ID         int       `db:"id"`
+	WebhookID  int       `db:"webhook_id"`
+	EventID    int       `db:"event_id"`
+	Action     string    `db:"action"`
+	Attempt    int       `db:"attempt"`
+	StatusCode int       `db:"status_code"`
+	Error      string    `db:"error"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (d deliveryDB) toDomain() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:         d.ID,
+		WebhookID:  d.WebhookID,
+		EventID:    d.EventID,
+		Action:     domain.EventAction(d.Action),
+		Attempt:    d.Attempt,
+		StatusCode: d.StatusCode,
+		Error:      d.Error,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w *domain.Webhook) (err error) {
+	query := `
+        INSERT INTO webhooks (user_id, url, secret, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+
+	ctx, span := startSpan(ctx, "webhooks.create", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return r.db.QueryRowContext(ctx, query, w.UserID, w.URL, w.Secret, w.CreatedAt).Scan(&w.ID)
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int) (err error) {
+	query := `DELETE FROM webhooks WHERE id = $1`
+
+	ctx, span := startSpan(ctx, "webhooks.delete", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Get(ctx context.Context, id int) (_ domain.Webhook, err error) {
+	query := `SELECT * FROM webhooks WHERE id = $1`
+
+	ctx, span := startSpan(ctx, "webhooks.get", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var hook webhookDB
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, &hook, query, id)
+	})
+	if err != nil {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+
+	return hook.toDomain(), nil
+}
+
+func (r *WebhookRepository) ListByUser(ctx context.Context, userID int) (_ []domain.Webhook, err error) {
+	query := `SELECT * FROM webhooks WHERE user_id = $1 ORDER BY id`
+
+	ctx, span := startSpan(ctx, "webhooks.list_by_user", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var hooksDB []webhookDB
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		hooksDB = nil
+		return db.SelectContext(ctx, &hooksDB, query, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := make([]domain.Webhook, len(hooksDB))
+	for i, hook := range hooksDB {
+		hooks[i] = hook.toDomain()
+	}
+
+	return hooks, nil
+}
+
+func (r *WebhookRepository) AddDelivery(ctx context.Context, d *domain.WebhookDelivery) (err error) {
+	query := `
+        INSERT INTO webhook_deliveries (webhook_id, event_id, action, attempt, status_code, error, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+
+	ctx, span := startSpan(ctx, "webhooks.add_delivery", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return r.db.QueryRowContext(ctx, query,
+		d.WebhookID, d.EventID, string(d.Action), d.Attempt, d.StatusCode, d.Error, d.CreatedAt,
+	).Scan(&d.ID)
+}
+
+func (r *WebhookRepository) ListDeliveries(
+	ctx context.Context,
+	webhookID int,
+) (_ []domain.WebhookDelivery, err error) {
+	query := `SELECT * FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id`
+
+	ctx, span := startSpan(ctx, "webhooks.list_deliveries", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	var deliveriesDB []deliveryDB
+	err = r.reads.read(ctx, func(db *sqlx.DB) error {
+		deliveriesDB = nil
+		return db.SelectContext(ctx, &deliveriesDB, query, webhookID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, len(deliveriesDB))
+	for i, d := range deliveriesDB {
+		deliveries[i] = d.toDomain()
+	}
+
+	return deliveries, nil
+}
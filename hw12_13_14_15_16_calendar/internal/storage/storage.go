@@ -1,21 +1,113 @@
 package storage
 
 import (
+	"context"
 	"time"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
 )
 
+type primaryKey struct{}
+
+// WithPrimary требует читать из основной базы, а не из реплики. Нужно, когда прочитанное
+// используется для записи или кешируется: реплика может отставать.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequired сообщает, задан ли для чтения WithPrimary.
+func PrimaryRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
+
 type Storage interface {
 	Event() EventRepository
+	Webhook() WebhookRepository
+	Channel() ChannelRepository
+	Reminder() ReminderRepository
+	Idempotency() IdempotencyRepository
+	Outbox() OutboxRepository
 }
 
 type EventRepository interface {
-	Create(e *domain.Event) error
-	Update(id int, e *domain.Event) error
-	Delete(id int) error
-	Get(id int) (domain.Event, error)
-	ListByDay(date time.Time) ([]domain.Event, error)
-	ListByWeek(date time.Time) ([]domain.Event, error)
-	ListByMonth(date time.Time) ([]domain.Event, error)
+	Create(ctx context.Context, e *domain.Event) error
+	Update(ctx context.Context, id int, e *domain.Event) error
+	Delete(ctx context.Context, id int) error
+	Get(ctx context.Context, id int) (domain.Event, error)
+	// ListByDay, ListByWeek и ListByMonth возвращают события userID за период, прошедшие фильтр тегов.
+	ListByDay(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByWeek(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	ListByMonth(ctx context.Context, userID int, date time.Time, tags domain.TagFilter) ([]domain.Event, error)
+	Count(ctx context.Context) (int, error)
+	// Search возвращает события по убыванию релевантности; q должен пройти Validate.
+	Search(ctx context.Context, q domain.SearchQuery) ([]domain.SearchResult, error)
+	// TagCounts возвращает число событий userID по каждому тегу, от частых к редким.
+	TagCounts(ctx context.Context, userID int) ([]domain.TagCount, error)
+	// ListAfter возвращает до limit событий userID с ID больше afterID в порядке ID;
+	// при userID 0 — события всех пользователей.
+	ListAfter(ctx context.Context, userID, afterID, limit int) ([]domain.Event, error)
+	// Restore сохраняет событие из резервной копии под его ID, если тот свободен, иначе под новым.
+	// Статусы напоминаний сохраняются.
+	Restore(ctx context.Context, e *domain.Event) error
+	// ListRestored возвращает события, которые Restore сохранил под новым ID, потому что sourceID был занят.
+	ListRestored(ctx context.Context, sourceID int) ([]domain.Event, error)
+	// CreateBatch создаёт все события одной операцией и проставляет им ID.
+	CreateBatch(ctx context.Context, events []*domain.Event) error
+	// UpdateBatch и DeleteBatch трогают только события userID; остальные, как и отсутствующие,
+	// получают ErrEventNotFound в поэлементных ошибках. В режиме BatchAllOrNothing
+	// такая ошибка отменяет весь пакет.
+	UpdateBatch(ctx context.Context, userID int, events []*domain.Event, mode domain.BatchMode) ([]error, error)
+	DeleteBatch(ctx context.Context, userID int, ids []int, mode domain.BatchMode) ([]domain.Event, []error, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, w *domain.Webhook) error
+	Delete(ctx context.Context, id int) error
+	Get(ctx context.Context, id int) (domain.Webhook, error)
+	ListByUser(ctx context.Context, userID int) ([]domain.Webhook, error)
+	AddDelivery(ctx context.Context, d *domain.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID int) ([]domain.WebhookDelivery, error)
+}
+
+type ChannelRepository interface {
+	Get(ctx context.Context, userID int) (domain.NotificationChannel, error)
+	Set(ctx context.Context, c *domain.NotificationChannel) error
+	Delete(ctx context.Context, userID int) error
+}
+
+// IdempotencyRepository хранит ключи идемпотентности; запись с ExpiresAt не позже
+// текущего момента считается отсутствующей.
+type IdempotencyRepository interface {
+	// Begin сохраняет rec, если для его ключа нет действующей записи, и возвращает (rec, true);
+	// иначе возвращает действующую запись и false. Текущим моментом считается rec.CreatedAt.
+	Begin(ctx context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error)
+	// Complete и Delete меняют запись, только пока ключ занят owner: запись, которую после
+	// истечения блокировки занял другой запрос, они не трогают. Complete в этом случае
+	// возвращает ErrIdempotencyKeyNotFound.
+	Complete(ctx context.Context, userID int, key, owner string, response []byte, expiresAt time.Time) error
+	Delete(ctx context.Context, userID int, key, owner string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type ReminderRepository interface {
+	ListDue(ctx context.Context, now time.Time) ([]domain.Reminder, error)
+	// Fire помечает напоминание отправленным и в той же транзакции сохраняет msg в outbox,
+	// проставляя ему ID. Уже отправленное напоминание даёт ErrReminderAlreadySent.
+	Fire(ctx context.Context, id int, sentAt time.Time, msg *domain.OutboxMessage) error
+}
+
+type OutboxRepository interface {
+	// ClaimPending закрепляет за owner до until не больше limit неопубликованных сообщений,
+	// которые никем не захвачены или чей захват истёк к now, и возвращает их в порядке ID.
+	// Свои сообщения owner получает повторно, продлевая захват. Сообщения, которые сейчас
+	// захватывает другой relay, пропускаются без ожидания.
+	ClaimPending(ctx context.Context, owner string, now, until time.Time, limit int) ([]domain.OutboxMessage, error)
+	MarkDispatched(ctx context.Context, ids []int, dispatchedAt time.Time) error
+	// MarkProcessed отмечает, что получатель обработал сообщение; Processed проверяет отметку.
+	// Удалённое сообщение считается необработанным.
+	MarkProcessed(ctx context.Context, id int, processedAt time.Time) error
+	Processed(ctx context.Context, id int) (bool, error)
+	// DeleteDispatched удаляет сообщения, опубликованные раньше before.
+	DeleteDispatched(ctx context.Context, before time.Time) (int, error)
 }
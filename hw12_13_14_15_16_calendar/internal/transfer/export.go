@@ -0,0 +1,110 @@
+// Package transfer выгружает события из хранилища и загружает их обратно — для резервных
+// копий и переезда между хранилищами. События читаются и пишутся потоком, по одной странице.
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/ical"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// pageSize — сколько событий читается из хранилища за один запрос.
+const pageSize = 500
+
+type Storage interface {
+	Event() storage.EventRepository
+}
+
+type Format string
+
+const (
+	// FormatJSONL — по событию в формате API на строку; такую выгрузку принимает Import.
+	FormatJSONL Format = "jsonl"
+	// FormatICS — iCalendar для календарных приложений; обратно не загружается.
+	FormatICS Format = "ics"
+)
+
+var ErrUnknownFormat = errors.New("format must be jsonl or ics")
+
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case FormatJSONL, FormatICS:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+type encoder interface {
+	Encode(event domain.Event) error
+	Close() error
+}
+
+// AllUsers вместо пользователя выгружает события всех пользователей.
+const AllUsers = 0
+
+// Export пишет события userID в порядке ID и возвращает их число. progress, если задан,
+// вызывается после каждой страницы с числом уже выгруженных событий.
+func Export(
+	ctx context.Context, s Storage, userID int, w io.Writer, format Format, progress func(done int),
+) (int, error) {
+	var enc encoder
+	switch format {
+	case FormatJSONL:
+		enc = newJSONLEncoder(w)
+	case FormatICS:
+		enc = ical.NewEncoder(w, time.Now())
+	default:
+		return 0, ErrUnknownFormat
+	}
+
+	done, afterID := 0, 0
+	for {
+		events, err := s.Event().ListAfter(ctx, userID, afterID, pageSize)
+		if err != nil {
+			return done, fmt.Errorf("failed to list events after %d: %w", afterID, err)
+		}
+
+		for _, event := range events {
+			if err := enc.Encode(event); err != nil {
+				return done, err
+			}
+			done++
+		}
+		if progress != nil && len(events) > 0 {
+			progress(done)
+		}
+		if len(events) < pageSize {
+			return done, enc.Close()
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &jsonlEncoder{w: bw, enc: enc}
+}
+
+func (e *jsonlEncoder) Encode(event domain.Event) error {
+	return e.enc.Encode(event)
+}
+
+func (e *jsonlEncoder) Close() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,199 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	ErrICSImport          = errors.New("ics export cannot be imported, use jsonl")
+	ErrCheckpointMismatch = errors.New("input does not match the checkpoint")
+)
+
+// checkpointEvery — через сколько строк Import сохраняет положение.
+var checkpointEvery = pageSize
+
+type Result struct {
+	// Imported — сохранённые события; из них Renumbered получили новый ID, потому что их ID был занят.
+	Imported   int
+	Renumbered int
+	// Existing — события, которые уже есть в хранилище под тем же ID, например после прерванной загрузки.
+	Existing int
+}
+
+// Checkpoint — положение прерванной загрузки: число обработанных строк и SHA-256 их содержимого.
+// По хешу Import узнаёт, что продолжает загрузку той же выгрузки.
+type Checkpoint struct {
+	Lines  int    `json:"lines"`
+	Digest string `json:"digest"`
+}
+
+// Import загружает выгрузку в формате JSONL через Restore, сохраняя ID событий, где это возможно.
+// Загрузка продолжается после from; если начало входа не совпадает с from, возвращается
+// ErrCheckpointMismatch. checkpoint, если задан, вызывается каждые checkpointEvery строк
+// и при остановке на ошибке; его ошибка прерывает загрузку. Если процесс упадёт, не дойдя
+// до checkpoint, при продолжении повторится не больше страницы строк, а уже сохранённые
+// из них события, в том числе получившие новый ID, будут учтены как Existing.
+func Import(
+	ctx context.Context, s Storage, r io.Reader, from Checkpoint, checkpoint func(Checkpoint, Result) error,
+) (Result, error) {
+	var result Result
+	reader := bufio.NewReader(r)
+	// В хеш попадают только обработанные строки, поэтому он всегда соответствует lines-1.
+	hash := sha256.New()
+	saved := from.Lines
+
+	save := func(lines int) error {
+		if checkpoint == nil || lines == saved {
+			return nil
+		}
+		saved = lines
+		return checkpoint(Checkpoint{Lines: lines, Digest: hex.EncodeToString(hash.Sum(nil))}, result)
+	}
+
+	for lines := 1; ; lines++ {
+		if err := ctx.Err(); err != nil {
+			return result, errors.Join(err, save(lines-1))
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			if lines <= from.Lines {
+				return result, fmt.Errorf("%w: input has %d lines, checkpoint is at line %d",
+					ErrCheckpointMismatch, lines-1, from.Lines)
+			}
+			return result, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return result, errors.Join(err, save(lines-1))
+		}
+
+		if lines <= from.Lines {
+			hash.Write(line)
+			if lines == from.Lines && hex.EncodeToString(hash.Sum(nil)) != from.Digest {
+				return result, fmt.Errorf("%w: first %d lines differ", ErrCheckpointMismatch, lines)
+			}
+			continue
+		}
+
+		if err := importLine(ctx, s, bytes.TrimSpace(line), &result); err != nil {
+			return result, errors.Join(fmt.Errorf("line %d: %w", lines, err), save(lines-1))
+		}
+		hash.Write(line)
+
+		if (lines-from.Lines)%checkpointEvery == 0 {
+			if err := save(lines); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+func importLine(ctx context.Context, s Storage, line []byte, result *Result) error {
+	if len(line) == 0 {
+		return nil
+	}
+	if bytes.HasPrefix(line, []byte("BEGIN:VCALENDAR")) {
+		return ErrICSImport
+	}
+
+	event, err := decodeEvent(line)
+	if err != nil {
+		return err
+	}
+
+	if event.ID > 0 {
+		loaded, err := alreadyLoaded(ctx, s, event)
+		if err != nil {
+			return err
+		}
+		if loaded {
+			result.Existing++
+			return nil
+		}
+	}
+
+	id := event.ID
+	if err := s.Event().Restore(ctx, &event); err != nil {
+		return err
+	}
+	result.Imported++
+	if event.ID != id {
+		result.Renumbered++
+	}
+	return nil
+}
+
+// alreadyLoaded сообщает, есть ли событие в хранилище: под своим ID или, если тот был занят,
+// под новым ID, который Restore выдал ему при прерванной загрузке. Читается основная база,
+// чтобы не загрузить событие повторно из-за отставания реплики.
+func alreadyLoaded(ctx context.Context, s Storage, event domain.Event) (bool, error) {
+	ctx = storage.WithPrimary(ctx)
+
+	existing, err := s.Event().Get(ctx, event.ID)
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	case sameEvent(existing, event):
+		return true, nil
+	}
+
+	restored, err := s.Event().ListRestored(ctx, event.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range restored {
+		if sameEvent(r, event) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// decodeEvent разбирает событие в формате API. Статусы напоминаний API при разборе
+// отбрасывает, поэтому они читаются отдельно: иначе после загрузки напоминания ушли бы повторно.
+func decodeEvent(line []byte) (domain.Event, error) {
+	var event domain.Event
+	if err := json.Unmarshal(line, &event); err != nil {
+		return domain.Event{}, err
+	}
+
+	var state struct {
+		Reminders []struct {
+			Status domain.ReminderStatus `json:"status"`
+			SentAt *time.Time            `json:"sentAt"`
+		} `json:"reminders"`
+	}
+	if err := json.Unmarshal(line, &state); err != nil {
+		return domain.Event{}, err
+	}
+	for i := range event.Reminders {
+		if i >= len(state.Reminders) || state.Reminders[i].Status != domain.ReminderSent {
+			continue
+		}
+		event.Reminders[i].Status = domain.ReminderSent
+		if sentAt := state.Reminders[i].SentAt; sentAt != nil {
+			event.Reminders[i].SentAt = *sentAt
+		}
+	}
+
+	return event, event.Validate()
+}
+
+func sameEvent(a, b domain.Event) bool {
+	return a.UserID == b.UserID && a.Title == b.Title && a.EventTime.Equal(b.EventTime) &&
+		a.Duration == b.Duration && a.AllDay == b.AllDay
+}
@@ -0,0 +1,215 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// seed создаёт count событий; у первого напоминание уже отправлено.
+func seed(t *testing.T, count int) *memorystorage.Storage {
+	t.Helper()
+
+	s := memorystorage.NewStorage()
+	start := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		event := &domain.Event{
+			Title:     "Standup",
+			EventTime: start.Add(time.Duration(i) * 24 * time.Hour),
+			Duration:  time.Hour,
+			UserID:    1,
+			Reminders: []domain.Reminder{{Offset: time.Hour}},
+		}
+		require.NoError(t, s.Event().Create(context.Background(), event))
+	}
+
+	reminders, err := s.Reminder().ListDue(context.Background(), start)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	require.NoError(t, s.Reminder().Fire(context.Background(), reminders[0].ID, start, &domain.OutboxMessage{}))
+	return s
+}
+
+func export(t *testing.T, s Storage, format Format) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), s, AllUsers, &buf, format, nil)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	source := seed(t, 3)
+	require.NoError(t, source.Event().Delete(context.Background(), 2))
+
+	var progress []int
+	var buf bytes.Buffer
+	n, err := Export(context.Background(), source, AllUsers, &buf, FormatJSONL,
+		func(done int) { progress = append(progress, done) })
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{2}, progress)
+
+	target := memorystorage.NewStorage()
+	result, err := Import(context.Background(), target, &buf, Checkpoint{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 2}, result)
+
+	// ID сохраняются вместе с пропуском, новые события получают следующие.
+	for _, id := range []int{1, 3} {
+		want, err := source.Event().Get(context.Background(), id)
+		require.NoError(t, err)
+		got, err := target.Event().Get(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, want.Title, got.Title)
+		assert.True(t, want.EventTime.Equal(got.EventTime))
+		assert.Equal(t, want.Reminders[0].Status, got.Reminders[0].Status)
+	}
+
+	// Отправленное напоминание не уходит повторно.
+	due, err := target.Reminder().ListDue(context.Background(), time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	event := &domain.Event{Title: "New", EventTime: time.Now(), Duration: time.Hour, UserID: 1}
+	require.NoError(t, target.Event().Create(context.Background(), event))
+	assert.Equal(t, 4, event.ID)
+}
+
+func TestImport_RenumbersTakenIDs(t *testing.T) {
+	dump := export(t, seed(t, 1), FormatJSONL)
+
+	target := memorystorage.NewStorage()
+	other := &domain.Event{Title: "Other", EventTime: time.Now(), Duration: time.Hour, UserID: 2}
+	require.NoError(t, target.Event().Create(context.Background(), other))
+
+	result, err := Import(context.Background(), target, strings.NewReader(dump), Checkpoint{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 1, Renumbered: 1}, result)
+
+	restored, err := target.Event().Get(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Standup", restored.Title)
+}
+
+func TestImport_ResumeAfterCrashMidPage(t *testing.T) {
+	dump := export(t, seed(t, 3), FormatJSONL)
+
+	// ID 1 и 2 заняты, поэтому первые события выгрузки получат новые ID.
+	target := memorystorage.NewStorage()
+	for i := 0; i < 2; i++ {
+		other := &domain.Event{Title: "Other", EventTime: time.Now(), Duration: time.Hour, UserID: 2}
+		require.NoError(t, target.Event().Create(context.Background(), other))
+	}
+
+	// Процесс упал после второй строки, не дойдя до сохранения положения.
+	lines := strings.SplitAfter(dump, "\n")
+	result, err := Import(context.Background(), target, strings.NewReader(lines[0]+lines[1]), Checkpoint{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 2, Renumbered: 2}, result)
+
+	result, err = Import(context.Background(), target, strings.NewReader(dump), Checkpoint{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 1, Renumbered: 1, Existing: 2}, result)
+
+	count, err := target.Event().Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+// importPages загружает dump, сохраняя положение каждые две строки; ctx отменяется
+// после первого сохранения.
+func importPages(t *testing.T, target Storage, dump string, from Checkpoint) (Result, []Checkpoint, error) {
+	t.Helper()
+
+	defer func(every int) { checkpointEvery = every }(checkpointEvery)
+	checkpointEvery = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var saved []Checkpoint
+	result, err := Import(ctx, target, strings.NewReader(dump), from, func(cp Checkpoint, _ Result) error {
+		saved = append(saved, cp)
+		cancel()
+		return nil
+	})
+	return result, saved, err
+}
+
+func TestImport_Resume(t *testing.T) {
+	dump := export(t, seed(t, 5), FormatJSONL)
+	target := memorystorage.NewStorage()
+
+	result, saved, err := importPages(t, target, dump, Checkpoint{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, saved, 1)
+	assert.Equal(t, 2, saved[0].Lines)
+	assert.Equal(t, Result{Imported: 2}, result)
+
+	// Вход, который не совпадает с сохранённым положением, не загружается.
+	other := strings.Replace(dump, "Standup", "Retro", 1)
+	_, _, err = importPages(t, target, other, saved[0])
+	assert.ErrorIs(t, err, ErrCheckpointMismatch)
+	_, _, err = importPages(t, target, strings.SplitAfter(dump, "\n")[0], saved[0])
+	assert.ErrorIs(t, err, ErrCheckpointMismatch)
+
+	result, err = Import(context.Background(), target, strings.NewReader(dump), saved[0], nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Imported: 3}, result)
+
+	// Повтор с начала не создаёт дублей.
+	result, err = Import(context.Background(), target, strings.NewReader(dump), Checkpoint{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Existing: 5}, result)
+
+	count, err := target.Event().Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestImport_CheckpointOnError(t *testing.T) {
+	dump := export(t, seed(t, 2), FormatJSONL) + "{\"title\": \"\"}\n"
+
+	var saved []Checkpoint
+	_, err := Import(context.Background(), memorystorage.NewStorage(), strings.NewReader(dump), Checkpoint{},
+		func(cp Checkpoint, _ Result) error {
+			saved = append(saved, cp)
+			return nil
+		})
+	require.ErrorContains(t, err, "line 3:")
+
+	// Страница не закончилась, но положение сохраняется на последней загруженной строке.
+	require.Len(t, saved, 1)
+	assert.Equal(t, 2, saved[0].Lines)
+
+	// С этого места продолжается загрузка того же входа.
+	_, err = Import(context.Background(), memorystorage.NewStorage(), strings.NewReader(dump), saved[0], nil)
+	assert.ErrorContains(t, err, "line 3:")
+}
+
+func TestImport_Errors(t *testing.T) {
+	ics := export(t, seed(t, 1), FormatICS)
+	assert.Contains(t, ics, "BEGIN:VEVENT")
+
+	_, err := Import(context.Background(), memorystorage.NewStorage(), strings.NewReader(ics), Checkpoint{}, nil)
+	assert.ErrorIs(t, err, ErrICSImport)
+
+	invalid := strings.NewReader("\n{\"title\": \"\"}\n")
+	_, err = Import(context.Background(), memorystorage.NewStorage(), invalid, Checkpoint{}, nil)
+	assert.ErrorContains(t, err, "line 2:")
+
+	_, err = ParseFormat("csv")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// maxRedirects повторяет ограничение http.Client по умолчанию.
+const maxRedirects = 10
+
+var (
+	// ErrForbiddenAddress — адрес вебхука ведёт во внутреннюю сеть, куда сервис ходить не должен.
+	ErrForbiddenAddress = errors.New("webhook address is not public")
+	ErrTooManyRedirects = errors.New("webhook stopped after 10 redirects")
+)
+
+// nonPublic — диапазоны, которые не покрывают проверки netip.Addr: «этот» хост,
+// адреса операторского NAT и сети для тестов производительности.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// isPublic сообщает, можно ли отправлять вебхук на ip: частные сети, loopback, link-local
+// (в том числе 169.254.169.254 с метаданными облака) и групповые адреса запрещены.
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// newClient возвращает клиент для доставки вебхуков. Если allowed задан, соединение
+// устанавливается только с адресами, которые он пропускает. Проверяется уже разрешённый
+// адрес каждого соединения, поэтому ни DNS-имя, ведущее во внутреннюю сеть, ни
+// перенаправление туда проверку не обходят.
+func newClient(timeout time.Duration, allowed func(netip.AddrPort) bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+
+	if allowed != nil {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !allowed(addr) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		}
+		// Через прокси соединение шло бы к нему, и проверка адреса вебхука потеряла бы смысл.
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrForbiddenAddress, req.URL.Scheme)
+			}
+			if len(via) >= maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+func publicAddr(addr netip.AddrPort) bool {
+	return isPublic(addr.Addr())
+}
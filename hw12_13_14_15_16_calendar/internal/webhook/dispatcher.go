@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	SignatureHeader = "X-Calendar-Signature"
+	ActionHeader    = "X-Calendar-Action"
+)
+
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Warn(args ...interface{})
+}
+
+type Storage interface {
+	Webhook() storage.WebhookRepository
+}
+
+type Dispatcher struct {
+	logger  Logger
+	storage Storage
+	client  *http.Client
+	conf    config.WebhooksConf
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	// mu упорядочивает wg.Add в Publish и начало Close: после закрытия доставки не начинаются.
+	mu     sync.Mutex
+	closed bool
+}
+
+type Payload struct {
+	Action    domain.EventAction `json:"action"`
+	Event     EventPayload       `json:"event"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+type EventPayload struct {
+	ID              int       `json:"id"`
+	Title           string    `json:"title"`
+	EventTime       time.Time `json:"eventTime"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	Description     string    `json:"description"`
+	UserID          int       `json:"userId"`
+	TimeToNotify    time.Time `json:"timeToNotify"`
+	Category        string    `json:"category"`
+	Color           string    `json:"color"`
+	Tags            []string  `json:"tags"`
+	AllDay          bool      `json:"allDay"`
+}
+
+func NewDispatcher(logger Logger, storage Storage, conf config.WebhooksConf) *Dispatcher {
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = 1
+	}
+	if conf.InitialBackoff <= 0 {
+		conf.InitialBackoff = time.Second
+	}
+	if conf.MaxBackoff < conf.InitialBackoff {
+		conf.MaxBackoff = conf.InitialBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	allowed := publicAddr
+	if conf.AllowPrivateNetworks {
+		allowed = nil
+	}
+
+	return &Dispatcher{
+		logger:  logger,
+		storage: storage,
+		client:  newClient(conf.Timeout, allowed),
+		conf:    conf,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Publish асинхронно рассылает изменение события всем вебхукам владельца события.
+func (d *Dispatcher) Publish(ctx context.Context, action domain.EventAction, event domain.Event) {
+	hooks, err := d.storage.Webhook().ListByUser(ctx, event.UserID)
+	if err != nil {
+		d.logger.Error(fmt.Sprintf("failed to list webhooks for user %d: %v", event.UserID, err))
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(Payload{
+		Action:    action,
+		Event:     newEventPayload(event),
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		d.logger.Error(fmt.Sprintf("failed to encode webhook payload: %v", err))
+		return
+	}
+
+	// Доставка переживает исходный запрос, поэтому от него берётся только трейс.
+	deliverCtx := trace.ContextWithSpanContext(d.ctx, trace.SpanContextFromContext(ctx))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		d.logger.Warn(fmt.Sprintf("dispatcher closed, %s of event %d not delivered", action, event.ID))
+		return
+	}
+
+	for _, hook := range hooks {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(deliverCtx, hook, action, event.ID, body)
+		}()
+	}
+}
+
+// Close ждёт завершения текущих доставок, а по истечении ctx прерывает оставшиеся повторы.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) deliver(
+	ctx context.Context, hook domain.Webhook, action domain.EventAction, eventID int, body []byte,
+) {
+	backoff := d.conf.InitialBackoff
+
+	for attempt := 1; attempt <= d.conf.MaxAttempts; attempt++ {
+		delivery := domain.WebhookDelivery{
+			WebhookID: hook.ID,
+			EventID:   eventID,
+			Action:    action,
+			Attempt:   attempt,
+		}
+
+		statusCode, err := d.send(ctx, hook, action, body)
+		delivery.StatusCode = statusCode
+		if err != nil {
+			delivery.Error = err.Error()
+		} else if statusCode < 200 || statusCode >= 300 {
+			delivery.Error = http.StatusText(statusCode)
+		}
+		delivery.CreatedAt = time.Now().UTC()
+
+		if err := d.storage.Webhook().AddDelivery(ctx, &delivery); err != nil {
+			d.logger.Error(fmt.Sprintf("failed to save webhook delivery: %v", err))
+		}
+
+		if delivery.Succeeded() {
+			return
+		}
+
+		d.logger.Warn(fmt.Sprintf("webhook %d delivery attempt %d/%d failed: %s",
+			hook.ID, attempt, d.conf.MaxAttempts, delivery.Error))
+
+		// Запрещённый адрес не станет разрешённым от повтора.
+		if attempt == d.conf.MaxAttempts || errors.Is(err, ErrForbiddenAddress) {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > d.conf.MaxBackoff {
+			backoff = d.conf.MaxBackoff
+		}
+	}
+
+	d.logger.Error(fmt.Sprintf("webhook %d delivery of %s for event %d abandoned", hook.ID, action, eventID))
+}
+
+func (d *Dispatcher) send(
+	ctx context.Context, hook domain.Webhook, action domain.EventAction, body []byte,
+) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ActionHeader, string(action))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает значение заголовка подписи: HMAC-SHA256 тела запроса в hex.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEventPayload(e domain.Event) EventPayload {
+	return EventPayload{
+		ID:              e.ID,
+		Title:           e.Title,
+		EventTime:       e.EventTime,
+		DurationSeconds: int64(e.Duration / time.Second),
+		Description:     e.Description,
+		UserID:          e.UserID,
+		TimeToNotify:    e.TimeToNotify,
+		Category:        e.Category,
+		Color:           e.Color,
+		Tags:            e.Tags,
+		AllDay:          e.AllDay,
+	}
+}
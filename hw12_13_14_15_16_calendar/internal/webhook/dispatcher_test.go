@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/domain"
+	memorystorage "github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+func (nopLogger) Warn(...interface{})  {}
+
+func testConf() config.WebhooksConf {
+	return config.WebhooksConf{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		// Тестовые приёмники слушают loopback.
+		AllowPrivateNetworks: true,
+	}
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	hook := &domain.Webhook{UserID: 1, URL: server.URL, Secret: "secret"}
+	require.NoError(t, storage.Webhook().Create(context.Background(), hook))
+
+	dispatcher := NewDispatcher(nopLogger{}, storage, testConf())
+	event := domain.Event{ID: 7, Title: "Meeting", EventTime: time.Now(), Duration: time.Hour, UserID: 1}
+	dispatcher.Publish(context.Background(), domain.EventCreated, event)
+	require.NoError(t, dispatcher.Close(context.Background()))
+
+	req := <-received
+	body := <-bodies
+
+	assert.Equal(t, Sign("secret", body), req.Header.Get(SignatureHeader))
+	assert.Equal(t, string(domain.EventCreated), req.Header.Get(ActionHeader))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, domain.EventCreated, payload.Action)
+	assert.Equal(t, 7, payload.Event.ID)
+	assert.Equal(t, int64(3600), payload.Event.DurationSeconds)
+
+	deliveries, err := storage.Webhook().ListDeliveries(context.Background(), hook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.True(t, deliveries[0].Succeeded())
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	hook := &domain.Webhook{UserID: 1, URL: server.URL, Secret: "secret"}
+	require.NoError(t, storage.Webhook().Create(context.Background(), hook))
+
+	dispatcher := NewDispatcher(nopLogger{}, storage, testConf())
+	dispatcher.Publish(context.Background(), domain.EventUpdated, domain.Event{ID: 1, UserID: 1})
+	require.NoError(t, dispatcher.Close(context.Background()))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	deliveries, err := storage.Webhook().ListDeliveries(context.Background(), hook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].StatusCode)
+	assert.Equal(t, 2, deliveries[1].Attempt)
+	assert.True(t, deliveries[2].Succeeded())
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	hook := &domain.Webhook{UserID: 1, URL: server.URL}
+	require.NoError(t, storage.Webhook().Create(context.Background(), hook))
+
+	dispatcher := NewDispatcher(nopLogger{}, storage, testConf())
+	dispatcher.Publish(context.Background(), domain.EventDeleted, domain.Event{ID: 1, UserID: 1})
+	require.NoError(t, dispatcher.Close(context.Background()))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	deliveries, err := storage.Webhook().ListDeliveries(context.Background(), hook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	for _, d := range deliveries {
+		assert.False(t, d.Succeeded())
+	}
+}
+
+func TestDispatcher_SkipsOtherUsers(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	require.NoError(t, storage.Webhook().Create(context.Background(), &domain.Webhook{UserID: 2, URL: server.URL}))
+
+	dispatcher := NewDispatcher(nopLogger{}, storage, testConf())
+	dispatcher.Publish(context.Background(), domain.EventCreated, domain.Event{ID: 1, UserID: 1})
+	require.NoError(t, dispatcher.Close(context.Background()))
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestDispatcher_RejectsPrivateAddresses(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	hook := &domain.Webhook{UserID: 1, URL: server.URL}
+	require.NoError(t, storage.Webhook().Create(context.Background(), hook))
+
+	conf := testConf()
+	conf.AllowPrivateNetworks = false
+	dispatcher := NewDispatcher(nopLogger{}, storage, conf)
+	dispatcher.Publish(context.Background(), domain.EventCreated, domain.Event{ID: 1, UserID: 1})
+	require.NoError(t, dispatcher.Close(context.Background()))
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	// Повторять доставку на запрещённый адрес бессмысленно.
+	deliveries, err := storage.Webhook().ListDeliveries(context.Background(), hook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].Error, ErrForbiddenAddress.Error())
+}
+
+func TestClient_ChecksRedirects(t *testing.T) {
+	var calls int32
+
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer internal.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer public.Close()
+
+	// Разрешён только адрес первого сервера, как если бы он был публичным.
+	publicAddr := netip.MustParseAddrPort(strings.TrimPrefix(public.URL, "http://"))
+	client := newClient(time.Second, func(addr netip.AddrPort) bool { return addr == publicAddr })
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, public.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:4700:4700::1111":   true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"::1":                    false,
+		"fe80::1":                false,
+		"fd00::1":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"224.0.0.1":              false,
+	} {
+		assert.Equal(t, public, isPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestDispatcher_PublishAfterClose(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	storage := memorystorage.NewStorage()
+	require.NoError(t, storage.Webhook().Create(context.Background(), &domain.Webhook{UserID: 1, URL: server.URL}))
+
+	dispatcher := NewDispatcher(nopLogger{}, storage, testConf())
+
+	// Publish, начатые одновременно с Close, либо успевают доставить, либо не начинают доставку.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.Publish(context.Background(), domain.EventCreated, domain.Event{ID: i, UserID: 1})
+		}()
+	}
+	require.NoError(t, dispatcher.Close(context.Background()))
+	wg.Wait()
+	delivered := atomic.LoadInt32(&calls)
+
+	dispatcher.Publish(context.Background(), domain.EventCreated, domain.Event{ID: 11, UserID: 1})
+	assert.Equal(t, delivered, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS notification_channels(
+    user_id INT PRIMARY KEY,
+    type VARCHAR(16) NOT NULL,
+    address VARCHAR(2048) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_channels;
+-- +goose StatementEnd
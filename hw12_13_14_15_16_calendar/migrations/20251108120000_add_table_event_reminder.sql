@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_reminders(
+    id SERIAL PRIMARY KEY,
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    remind_before BIGINT NOT NULL,
+    fire_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    sent_at TIMESTAMP NULL,
+    UNIQUE (event_id, remind_before)
+);
+
+CREATE INDEX IF NOT EXISTS event_reminders_pending_idx ON event_reminders(fire_at) WHERE status = 'pending';
+
+-- Переносим единственное абсолютное время уведомления в относительное напоминание.
+INSERT INTO event_reminders (event_id, remind_before, fire_at)
+SELECT id, (EXTRACT(EPOCH FROM (event_time - time_to_notify)) * 1000000000)::BIGINT, time_to_notify
+FROM events
+WHERE time_to_notify <= event_time;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_reminders;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Выражение должно совпадать с searchVector в sqlstorage, иначе индекс не будет использоваться.
+CREATE INDEX IF NOT EXISTS events_search_idx ON events USING GIN (
+    (setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', description), 'B'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS events_search_idx;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS color VARCHAR(7) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS event_tags(
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (event_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS event_tags_tag_idx ON event_tags(tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_tags;
+ALTER TABLE events DROP COLUMN color, DROP COLUMN category;
+-- +goose StatementEnd
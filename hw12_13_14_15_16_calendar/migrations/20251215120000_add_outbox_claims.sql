@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP COLUMN IF EXISTS claimed_by,
+    DROP COLUMN IF EXISTS claimed_until,
+    DROP COLUMN IF EXISTS processed_at;
+-- +goose StatementEnd
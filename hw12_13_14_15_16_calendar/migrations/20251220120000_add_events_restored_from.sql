@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN IF NOT EXISTS restored_from INTEGER;
+CREATE INDEX IF NOT EXISTS events_restored_from_idx ON events (restored_from) WHERE restored_from IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS events_restored_from_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS restored_from;
+-- +goose StatementEnd
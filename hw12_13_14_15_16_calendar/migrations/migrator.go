@@ -1,19 +1,38 @@
 package migrations
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"strings"
 
 	"github.com/gomonov/otus-go/hw12_13_14_15_calendar/internal/config"
 	_ "github.com/lib/pq" // Register postgres driver
 	"github.com/pressly/goose/v3"
 )
 
+// FS содержит SQL-миграции, вшитые в бинарник, поэтому каталог migrations на диске не нужен.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// embeddedDir — каталог миграций внутри FS.
+const embeddedDir = "."
+
 type Logger interface {
 	Info(args ...interface{})
 	Error(args ...interface{})
 }
 
+func init() {
+	goose.SetBaseFS(FS)
+}
+
 func AutoMigrate(logger Logger, cfg *config.Config) error {
 	if !cfg.Migrations.AutoMigrate {
 		logger.Info("Auto migrations disabled")
@@ -25,21 +44,248 @@ func AutoMigrate(logger Logger, cfg *config.Config) error {
 		return nil
 	}
 
-	db, err := sql.Open("postgres", cfg.Storage.Dsn)
+	db, err := Open(context.Background(), cfg.Storage.Dsn)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("database ping failed: %w", err)
+	logger.Info("Applying migrations...")
+	if err := NewMigrator(logger, db, nil).Up(context.Background()); err != nil {
+		return err
 	}
 
-	logger.Info("Applying migrations...")
-	if err := goose.Up(db, cfg.Migrations.Dir); err != nil {
+	logger.Info("Migrations applied successfully")
+	return nil
+}
+
+// Open подключается к БД, в которой выполняются миграции.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, errors.New("storage dsn is empty")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+
+	return db, nil
+}
+
+// Migrator выполняет команды goose над вшитыми миграциями.
+// Если задан dryRun, команды не меняют БД, а печатают SQL, который был бы выполнен.
+type Migrator struct {
+	logger Logger
+	db     *sql.DB
+	dryRun io.Writer
+}
+
+func NewMigrator(logger Logger, db *sql.DB, dryRun io.Writer) *Migrator {
+	goose.SetLogger(gooseLogger{logger})
+	return &Migrator{logger: logger, db: db, dryRun: dryRun}
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	if m.dryRun != nil {
+		pending, err := m.pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			m.logger.Info("no pending migrations")
+		}
+		return m.printSQL(pending, true)
+	}
+
+	if err := goose.UpContext(ctx, m.db, embeddedDir); err != nil {
 		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
+	return nil
+}
 
-	logger.Info("Migrations applied successfully")
+func (m *Migrator) Down(ctx context.Context) error {
+	if m.dryRun != nil {
+		current, err := m.current(ctx)
+		if err != nil || current == nil {
+			return err
+		}
+		return m.printSQL([]*goose.Migration{current}, false)
+	}
+
+	if err := goose.DownContext(ctx, m.db, embeddedDir); err != nil {
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Redo(ctx context.Context) error {
+	if m.dryRun != nil {
+		current, err := m.current(ctx)
+		if err != nil || current == nil {
+			return err
+		}
+		if err := m.printSQL([]*goose.Migration{current}, false); err != nil {
+			return err
+		}
+		return m.printSQL([]*goose.Migration{current}, true)
+	}
+
+	if err := goose.RedoContext(ctx, m.db, embeddedDir); err != nil {
+		return fmt.Errorf("failed to redo migration: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) error {
+	if err := goose.StatusContext(ctx, m.db, embeddedDir); err != nil {
+		return fmt.Errorf("failed to get migrations status: %w", err)
+	}
+	return nil
+}
+
+// Create создаёт пустую SQL-миграцию в каталоге исходников dir.
+func Create(logger Logger, dir, name string) error {
+	if name == "" {
+		return errors.New("migration name is required")
+	}
+	goose.SetLogger(gooseLogger{logger})
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// CheckApplied возвращает ошибку, если в БД применены не все вшитые миграции.
+func CheckApplied(ctx context.Context, db *sql.DB) error {
+	migrations, err := goose.CollectMigrations(embeddedDir, 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %w", err)
+	}
+
+	latest, err := migrations.Last()
+	if err != nil {
+		return fmt.Errorf("failed to get latest migration: %w", err)
+	}
+
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to get db version: %w", err)
+	}
+
+	if current < latest.Version {
+		return fmt.Errorf("database version %d is behind latest migration %d", current, latest.Version)
+	}
+
+	return nil
+}
+
+// dbVersion возвращает текущую версию схемы. В отличие от goose.GetDBVersion
+// не создаёт таблицу версий, чтобы dry-run ничего не менял в БД.
+func (m *Migrator) dbVersion(ctx context.Context) (int64, error) {
+	var table sql.NullString
+	if err := m.db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", goose.TableName()).Scan(&table); err != nil {
+		return 0, fmt.Errorf("failed to get db version: %w", err)
+	}
+	if !table.Valid {
+		return 0, nil
+	}
+
+	version, err := goose.GetDBVersionContext(ctx, m.db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get db version: %w", err)
+	}
+	return version, nil
+}
+
+func (m *Migrator) pending(ctx context.Context) ([]*goose.Migration, error) {
+	current, err := m.dbVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := goose.CollectMigrations(embeddedDir, current+1, goose.MaxVersion)
+	if err != nil && !errors.Is(err, goose.ErrNoMigrationFiles) {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	return migrations, nil
+}
+
+// current возвращает последнюю применённую миграцию или nil, если откатывать нечего.
+func (m *Migrator) current(ctx context.Context) (*goose.Migration, error) {
+	version, err := m.dbVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		m.logger.Info("no migrations to roll back")
+		return nil, nil
+	}
+
+	migrations, err := goose.CollectMigrations(embeddedDir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	return migrations.Current(version)
+}
+
+func (m *Migrator) printSQL(migrations []*goose.Migration, up bool) error {
+	direction := "Down"
+	if up {
+		direction = "Up"
+	}
+
+	for _, migration := range migrations {
+		content, err := fs.ReadFile(FS, migration.Source)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(m.dryRun, "-- %s %s\n%s\n", direction, migration.Source, SQLSection(string(content), up))
+	}
 	return nil
 }
+
+// SQLSection возвращает текст секции Up или Down из файла миграции goose.
+func SQLSection(content string, up bool) string {
+	want := "-- +goose Down"
+	if up {
+		want = "-- +goose Up"
+	}
+
+	var b strings.Builder
+	inSection := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "-- +goose Up") || strings.HasPrefix(trimmed, "-- +goose Down") {
+			inSection = strings.HasPrefix(trimmed, want)
+			continue
+		}
+		if !inSection || strings.HasPrefix(trimmed, "-- +goose ") {
+			continue
+		}
+
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// gooseLogger направляет вывод goose в логгер приложения.
+type gooseLogger struct {
+	logger Logger
+}
+
+func (l gooseLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimRight(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (l gooseLogger) Fatalf(format string, v ...interface{}) {
+	l.logger.Error(strings.TrimRight(fmt.Sprintf(format, v...), "\n"))
+}
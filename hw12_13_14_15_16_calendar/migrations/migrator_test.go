@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := goose.CollectMigrations(embeddedDir, 0, goose.MaxVersion)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	first := migrations[0]
+	assert.Equal(t, int64(20251017213819), first.Version)
+}
+
+func TestSQLSection(t *testing.T) {
+	content := `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE t (id INT);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE t;
+`
+
+	assert.Equal(t, "CREATE TABLE t (id INT);", SQLSection(content, true))
+	assert.Equal(t, "DROP TABLE t;", SQLSection(content, false))
+}